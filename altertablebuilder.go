@@ -0,0 +1,272 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	alterTable = " ALTER TABLE "
+	dropTable  = " DROP TABLE "
+	truncate   = " TRUNCATE "
+	ifExists   = "IF EXISTS "
+)
+
+type renameDef struct {
+	from string
+	to   string
+}
+
+// The type of alter table builder which wrap the ALTER TABLE statement.
+// Only one kind of change(ADD, DROP, RENAME or WITH) is allowed in a
+// statement, as Cassandra does.
+// Example:
+//
+//	AlterTable("users").AddColumn("email", Text).AddColumn("tags", SetOf(Text))
+//	AlterTable("users").DropColumn("legacy")
+//	AlterTable("users").With("gc_grace_seconds", 3600)
+type AlterTableBuilder struct {
+	table   string
+	adds    []columnDef
+	drops   []string
+	renames []renameDef
+	options tableOptions
+}
+
+// Create the alter table builder.
+func AlterTable(t string) *AlterTableBuilder {
+	return &AlterTableBuilder{table: t}
+}
+
+// Add the column.
+func (c *AlterTableBuilder) AddColumn(name string, typ CqlType) *AlterTableBuilder {
+	c.adds = append(c.adds, columnDef{name: name, typ: typ})
+	return c
+}
+
+// Add the static column.
+func (c *AlterTableBuilder) AddStaticColumn(name string, typ CqlType) *AlterTableBuilder {
+	c.adds = append(c.adds, columnDef{name: name, typ: typ, static: true})
+	return c
+}
+
+// Drop the column.
+func (c *AlterTableBuilder) DropColumn(name string) *AlterTableBuilder {
+	c.drops = append(c.drops, name)
+	return c
+}
+
+// Rename the column, Cassandra only allows renaming the primary key columns.
+func (c *AlterTableBuilder) RenameColumn(from, to string) *AlterTableBuilder {
+	c.renames = append(c.renames, renameDef{from: from, to: to})
+	return c
+}
+
+// Set the table option, see CreateTableBuilder.With.
+func (c *AlterTableBuilder) With(name string, value interface{}) *AlterTableBuilder {
+	c.options.set(name, value)
+	return c
+}
+
+// Set the compaction strategy class and its sub options.
+func (c *AlterTableBuilder) Compaction(class string, opts map[string]interface{}) *AlterTableBuilder {
+	return c.With("compaction", compactionOption(class, opts))
+}
+
+// Set default_time_to_live in seconds.
+func (c *AlterTableBuilder) DefaultTTL(seconds int) *AlterTableBuilder {
+	return c.With("default_time_to_live", seconds)
+}
+
+// Set gc_grace_seconds.
+func (c *AlterTableBuilder) GcGraceSeconds(seconds int) *AlterTableBuilder {
+	return c.With("gc_grace_seconds", seconds)
+}
+
+// Validate
+func (c *AlterTableBuilder) Validate() error {
+	if len(c.table) == 0 {
+		return errEmptyTable
+	}
+
+	changes := 0
+	for _, n := range []int{len(c.adds), len(c.drops), len(c.renames), len(c.options.names)} {
+		if n > 0 {
+			changes++
+		}
+	}
+	if changes != 1 {
+		return &SchemaError{Statement: "ALTER TABLE " + c.table, Reason: "need exactly one kind of change(ADD, DROP, RENAME or WITH)"}
+	}
+
+	for _, col := range c.adds {
+		if err := col.typ.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Build the ALTER TABLE statement, it has no values.
+// Example:
+//
+//	ALTER TABLE users ADD (email text,tags set<text>)
+//	ALTER TABLE users RENAME id TO user_id
+func (c *AlterTableBuilder) ToQuery() (string, []interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(alterTable)
+	buf.WriteString(table)
+
+	switch {
+	case len(c.adds) > 0:
+		defs := make([]string, len(c.adds))
+		for i, col := range c.adds {
+			name, err := QuoteIdentifier(col.name)
+			if err != nil {
+				return "", nil, err
+			}
+			typ, err := col.typ.render()
+			if err != nil {
+				return "", nil, err
+			}
+
+			defs[i] = name + space + typ
+			if col.static {
+				defs[i] += static
+			}
+		}
+		buf.WriteString(" ADD ")
+		buf.WriteString(parenthesize(defs))
+
+	case len(c.drops) > 0:
+		names, err := quoteIdentifiers(c.drops)
+		if err != nil {
+			return "", nil, err
+		}
+		buf.WriteString(" DROP ")
+		buf.WriteString(parenthesize(names))
+
+	case len(c.renames) > 0:
+		buf.WriteString(" RENAME ")
+		for i, r := range c.renames {
+			names, err := quoteIdentifiers([]string{r.from, r.to})
+			if err != nil {
+				return "", nil, err
+			}
+			if i > 0 {
+				buf.WriteString(and)
+			}
+			buf.WriteString(names[0] + " TO " + names[1])
+		}
+
+	default:
+		opts, err := c.options.render()
+		if err != nil {
+			return "", nil, err
+		}
+		buf.WriteString(with)
+		buf.WriteString(opts)
+	}
+
+	return buf.String(), nil, nil
+}
+
+// The single item as is, more than one are joined in parentheses.
+func parenthesize(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return leftPar + strings.Join(items, comma) + rightPar
+}
+
+func (c *AlterTableBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *AlterTableBuilder) statementKind() StatementKind {
+	return KindSchema
+}
+
+// The type of drop table builder which wrap the DROP TABLE statement.
+type DropTableBuilder struct {
+	table    string
+	ifExists bool
+}
+
+// Create the drop table builder.
+func DropTable(t string) *DropTableBuilder {
+	return &DropTableBuilder{table: t}
+}
+
+// Set if exists
+func (c *DropTableBuilder) IfExists(e bool) *DropTableBuilder {
+	c.ifExists = e
+	return c
+}
+
+// Build the DROP TABLE statement, it has no values.
+func (c *DropTableBuilder) ToQuery() (string, []interface{}, error) {
+	if len(c.table) == 0 {
+		return "", nil, errEmptyTable
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	str := dropTable
+	if c.ifExists {
+		str += ifExists
+	}
+	return str + table, nil, nil
+}
+
+func (c *DropTableBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *DropTableBuilder) statementKind() StatementKind {
+	return KindSchema
+}
+
+// The type of truncate builder which wrap the TRUNCATE statement.
+type TruncateBuilder struct {
+	table string
+}
+
+// Create the truncate builder.
+func Truncate(t string) *TruncateBuilder {
+	return &TruncateBuilder{table: t}
+}
+
+// Build the TRUNCATE statement, it has no values.
+func (c *TruncateBuilder) ToQuery() (string, []interface{}, error) {
+	if len(c.table) == 0 {
+		return "", nil, errEmptyTable
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+	return truncate + table, nil, nil
+}
+
+func (c *TruncateBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *TruncateBuilder) statementKind() StatementKind {
+	return KindSchema
+}
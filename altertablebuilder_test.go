@@ -0,0 +1,50 @@
+package cqlbuilder
+
+import (
+	"testing"
+)
+
+func TestAlterTable(t *testing.T) {
+	cases := []struct {
+		builder  CqlBuilder
+		expected string
+	}{
+		{AlterTable("users").AddColumn("email", Text), " ALTER TABLE users ADD email text"},
+		{AlterTable("ks.users").AddColumn("email", Text).AddStaticColumn("tags", SetOf(Text)), " ALTER TABLE ks.users ADD (email text,tags set<text> STATIC)"},
+		{AlterTable("users").DropColumn("legacy"), " ALTER TABLE users DROP legacy"},
+		{AlterTable("users").DropColumn("a").DropColumn("userId"), ` ALTER TABLE users DROP (a,userId)`},
+		{AlterTable("users").RenameColumn("id", "user_id").RenameColumn("ts", "created"), " ALTER TABLE users RENAME id TO user_id AND ts TO created"},
+		{AlterTable("users").GcGraceSeconds(3600).With("comment", "users"), " ALTER TABLE users WITH gc_grace_seconds = 3600 AND comment = 'users'"},
+		{AlterTable("users").Compaction("LeveledCompactionStrategy", nil), " ALTER TABLE users WITH compaction = {'class': 'LeveledCompactionStrategy'}"},
+		{DropTable("users"), " DROP TABLE users"},
+		{DropTable("ks.Users").IfExists(true), ` DROP TABLE IF EXISTS ks.Users`},
+		{Truncate("ks.users"), " TRUNCATE ks.users"},
+	}
+
+	for _, c := range cases {
+		str, vals, err := c.builder.ToQuery()
+		if err != nil || str != c.expected || len(vals) != 0 {
+			t.Logf("str %s err %v, expected %s", str, err, c.expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestAlterTableValidate(t *testing.T) {
+	cases := []CqlBuilder{
+		AlterTable("users"),
+		AlterTable("").AddColumn("a", Int),
+		AlterTable("users").AddColumn("a", Int).DropColumn("b"),
+		AlterTable("users").AddColumn("m", MapOf(Text, ListOf(Int))),
+		AlterTable("users").With("bad name", 1),
+		DropTable(""),
+		Truncate("a.b.c"),
+	}
+
+	for i, c := range cases {
+		if _, _, err := c.ToQuery(); err == nil {
+			t.Logf("case %d: err expected", i)
+			t.FailNow()
+		}
+	}
+}
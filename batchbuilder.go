@@ -2,9 +2,20 @@ package cqlbuilder
 
 type BatchBuilder struct {
 	builders []CqlBuilder
+	options  *QueryOptions
 }
 
 func (b *BatchBuilder) Add(builder CqlBuilder) *BatchBuilder {
 	b.builders = append(b.builders, builder)
 	return b
 }
+
+// Attach the query options(consistency, page size ...) to the statement.
+func (b *BatchBuilder) SetOptions(o *QueryOptions) *BatchBuilder {
+	b.options = o
+	return b
+}
+
+func (b *BatchBuilder) queryOptions() *QueryOptions {
+	return b.options
+}
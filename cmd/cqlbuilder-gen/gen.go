@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	cb "cqlbuilder"
+)
+
+// The config of the generated file.
+type genConfig struct {
+	Package string
+	// The import path of the cqlbuilder package.
+	Import string
+	// The schema files, written in the header.
+	Source []string
+}
+
+// The imports of the go types, the package name => import line.
+var typeImports = map[string]string{
+	"cql":  `cql "github.com/gocql/gocql"`,
+	"time": `"time"`,
+	"big":  `"math/big"`,
+	"net":  `"net"`,
+	"inf":  `"gopkg.in/inf.v0"`,
+}
+
+// The go types of the native CQL types, the ones gocql unmarshal to by
+// default.
+var goTypes = map[string]string{
+	"ascii":     "string",
+	"bigint":    "int64",
+	"blob":      "[]byte",
+	"boolean":   "bool",
+	"counter":   "int64",
+	"date":      "time.Time",
+	"decimal":   "*inf.Dec",
+	"double":    "float64",
+	"duration":  "cql.Duration",
+	"float":     "float32",
+	"inet":      "net.IP",
+	"int":       "int",
+	"smallint":  "int16",
+	"text":      "string",
+	"time":      "time.Duration",
+	"timestamp": "time.Time",
+	"timeuuid":  "cql.UUID",
+	"tinyint":   "int8",
+	"uuid":      "cql.UUID",
+	"varchar":   "string",
+	"varint":    "*big.Int",
+}
+
+// The generator of one file, it collects the imports while writing the
+// tables.
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// Generate the go source of the tables, it's gofmt-ed.
+func generate(cfg *genConfig, tables []*cb.TableSchema) ([]byte, error) {
+	g := &generator{imports: map[string]bool{}}
+
+	seen := map[string]string{}
+	for _, t := range tables {
+		name := exportedName(t.Name)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("table %s and %s have the same go name %s", other, qualified(t), name)
+		}
+		seen[name] = qualified(t)
+
+		if err := g.table(t); err != nil {
+			return nil, err
+		}
+	}
+
+	var file bytes.Buffer
+	sources := make([]string, len(cfg.Source))
+	for i, s := range cfg.Source {
+		sources[i] = filepath.Base(s)
+	}
+	fmt.Fprintf(&file, "// Code generated by cqlbuilder-gen from %s. DO NOT EDIT.\n\n", strings.Join(sources, ", "))
+	fmt.Fprintf(&file, "package %s\n\n", cfg.Package)
+
+	// The standard packages first, then the third party ones.
+	var std, third []string
+	for pkg := range g.imports {
+		if imp := typeImports[pkg]; strings.Contains(imp, ".") {
+			third = append(third, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+	third = append(third, fmt.Sprintf("cb %q", cfg.Import))
+	sort.Strings(std)
+	sort.Strings(third)
+
+	file.WriteString("import (\n")
+	for _, imp := range std {
+		file.WriteString(imp + "\n")
+	}
+	if len(std) > 0 {
+		file.WriteString("\n")
+	}
+	for _, imp := range third {
+		file.WriteString(imp + "\n")
+	}
+	file.WriteString(")\n")
+	file.Write(g.buf.Bytes())
+
+	src, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+func qualified(t *cb.TableSchema) string {
+	if len(t.Keyspace) == 0 {
+		return t.Name
+	}
+	return t.Keyspace + "." + t.Name
+}
+
+// The key column of the generated helpers.
+type keyParam struct {
+	name   string
+	typ    string
+	column string
+}
+
+// Write the constants, the row struct and the helpers of the table.
+func (g *generator) table(t *cb.TableSchema) error {
+	name := exportedName(t.Name)
+	table := qualified(t)
+	w := &g.buf
+
+	fmt.Fprintf(w, "\n// The table %s.\nconst %sTable = %q\n", table, name, table)
+
+	fields := make([]string, len(t.Columns))
+	types := make([]string, len(t.Columns))
+	consts := make([]string, len(t.Columns))
+	counter := false
+	usedFields := map[string]bool{}
+	for i, col := range t.Columns {
+		typ, err := g.goType(col.Type)
+		if err != nil {
+			return fmt.Errorf("table %s column %s: %w", table, col.Name, err)
+		}
+		types[i] = typ
+		counter = counter || col.Type.Name == cb.Counter.Name
+
+		fields[i] = exportedName(col.Name)
+		for usedFields[fields[i]] {
+			fields[i] += "_"
+		}
+		usedFields[fields[i]] = true
+		consts[i] = name + fields[i] + "Col"
+	}
+
+	fmt.Fprintf(w, "\n// The columns of %s.\nconst (\n", table)
+	for i, col := range t.Columns {
+		fmt.Fprintf(w, "%s = %q\n", consts[i], cqlName(col.Name))
+	}
+	w.WriteString(")\n")
+
+	fmt.Fprintf(w, "\n// All the columns of %s, in the order of %sRow.\nvar %sColumns = []string{%s}\n",
+		table, name, name, strings.Join(consts, ", "))
+
+	fmt.Fprintf(w, "\n// The row of %s.\ntype %sRow struct {\n", table, name)
+	for i, col := range t.Columns {
+		tag := cqlName(col.Name)
+		switch {
+		case contains(t.PartitionKeys, col.Name):
+			tag += ",pk"
+		case contains(t.ClusteringKeys, col.Name):
+			tag += ",ck"
+		default:
+			// The zero value is not inserted, so it doesn't write the null.
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(w, "%s %s `cql:%q`\n", fields[i], types[i], tag)
+	}
+	w.WriteString("}\n")
+
+	index := map[string]int{}
+	for i, col := range t.Columns {
+		index[col.Name] = i
+	}
+	usedParams := map[string]bool{}
+	keyOf := func(cols []string) []keyParam {
+		keys := make([]keyParam, len(cols))
+		for i, c := range cols {
+			j := index[c]
+			p := unexportedName(fields[j])
+			for usedParams[p] || token.IsKeyword(p) || p == "cb" || typeImports[p] != "" {
+				p += "_"
+			}
+			usedParams[p] = true
+			keys[i] = keyParam{name: p, typ: types[j], column: consts[j]}
+		}
+		return keys
+	}
+	pks := keyOf(t.PartitionKeys)
+	cks := keyOf(t.ClusteringKeys)
+	keys := append(append([]keyParam(nil), pks...), cks...)
+
+	fmt.Fprintf(w, "\n// Select the row of %s by the primary key.\n", table)
+	fmt.Fprintf(w, "func Select%s(%s) *cb.SelectBuilder {\n", name, params(keys))
+	fmt.Fprintf(w, "return cb.Select(%sTable).AddColumns(%sColumns...)%s\n}\n", name, name, wheres(keys))
+
+	if len(cks) > 0 {
+		fmt.Fprintf(w, "\n// Select the rows of %s by the partition key.\n", table)
+		fmt.Fprintf(w, "func Select%sPartition(%s) *cb.SelectBuilder {\n", name, params(pks))
+		fmt.Fprintf(w, "return cb.Select(%sTable).AddColumns(%sColumns...)%s\n}\n", name, name, wheres(pks))
+	}
+
+	// The counter columns can't be inserted, only updated.
+	if !counter {
+		fmt.Fprintf(w, "\n// Insert the row of %s, the columns of zero value are not set.\n", table)
+		fmt.Fprintf(w, "func Insert%s(r *%sRow) (*cb.InsertBuilder, error) {\nreturn cb.InsertStruct(%sTable, r)\n}\n", name, name, name)
+	}
+
+	fmt.Fprintf(w, "\n// Update the row of %s by the primary key, the values are set by\n// the caller.\n", table)
+	fmt.Fprintf(w, "func Update%s(%s) *cb.UpdateBuilder {\n", name, params(keys))
+	fmt.Fprintf(w, "return cb.Update(%sTable)%s\n}\n", name, wheres(keys))
+
+	fmt.Fprintf(w, "\n// Delete the row of %s by the primary key.\n", table)
+	fmt.Fprintf(w, "func Delete%s(%s) *cb.DeleteBuilder {\n", name, params(keys))
+	fmt.Fprintf(w, "return cb.Delete(%sTable)%s\n}\n", name, wheres(keys))
+
+	return nil
+}
+
+func params(keys []keyParam) string {
+	ps := make([]string, len(keys))
+	for i, k := range keys {
+		ps[i] = k.name + " " + k.typ
+	}
+	return strings.Join(ps, ", ")
+}
+
+func wheres(keys []keyParam) string {
+	var buf bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&buf, ".\nWhere(cb.Eq(%s, %s))", k.column, k.name)
+	}
+	return buf.String()
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// The go type of the CQL type. The tuple is []interface{} and the user
+// defined type is map[string]interface{}, the ones gocql supports without
+// the marshaler.
+func (g *generator) goType(t cb.CqlType) (string, error) {
+	if t.UDT {
+		return "map[string]interface{}", nil
+	}
+
+	switch t.Name {
+	case "frozen":
+		return g.goType(t.Params[0])
+	case "list", "set":
+		elem, err := g.goType(t.Params[0])
+		return "[]" + elem, err
+	case "map":
+		key, err := g.goType(t.Params[0])
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(key, "[]") || strings.HasPrefix(key, "map[") {
+			return "", fmt.Errorf("map key %s can't be the go map key", t.Params[0])
+		}
+		val, err := g.goType(t.Params[1])
+		return "map[" + key + "]" + val, err
+	case "tuple":
+		return "[]interface{}", nil
+	}
+
+	typ, ok := goTypes[t.Name]
+	if !ok {
+		return "", fmt.Errorf("unsupported type %s", t)
+	}
+	if i := strings.IndexByte(typ, '.'); i > 0 {
+		g.imports[strings.TrimPrefix(typ[:i], "*")] = true
+	}
+	return typ, nil
+}
+
+// The column name used in CQL, the name which isn't lower case is quoted or
+// Cassandra folds it to lower case.
+func cqlName(s string) string {
+	if s == strings.ToLower(s) {
+		return s
+	}
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
+// The exported go name of the CQL name, org_id => OrgId, displayName =>
+// DisplayName.
+func exportedName(s string) string {
+	var buf strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if buf.Len() == 0 && unicode.IsDigit(r) {
+			buf.WriteByte('X')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		buf.WriteRune(r)
+	}
+	if buf.Len() == 0 {
+		return "X"
+	}
+	return buf.String()
+}
+
+// The unexported go name, OrgId => orgId.
+func unexportedName(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
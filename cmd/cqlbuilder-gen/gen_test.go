@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cb "cqlbuilder"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.cql")
+	if err != nil || len(files) == 0 {
+		t.Logf("no schema files %v", err)
+		t.FailNow()
+	}
+
+	for _, f := range files {
+		ks, err := cb.LoadSchemaFile(f)
+		if err != nil {
+			t.Logf("load %s %v", f, err)
+			t.FailNow()
+		}
+
+		src, err := generate(&genConfig{Package: "models", Import: "cqlbuilder", Source: []string{f}}, ks.Tables)
+		if err != nil {
+			t.Logf("generate %s %v", f, err)
+			t.FailNow()
+		}
+
+		golden := strings.TrimSuffix(f, ".cql") + ".golden"
+		if *update {
+			if err := ioutil.WriteFile(golden, src, 0644); err != nil {
+				t.Logf("write %s %v", golden, err)
+				t.FailNow()
+			}
+			continue
+		}
+
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil || !bytes.Equal(src, expected) {
+			t.Logf("generated code of %s differs from %s, run go test -update\n%s", f, golden, src)
+			t.FailNow()
+		}
+
+		checkCompile(t, f, src)
+	}
+}
+
+// Build and test the generated code, in the package under testdata so it's
+// built with the module. The test of the schema file, foo_test.go.in of
+// foo.cql, is run with the code if it exists.
+func checkCompile(t *testing.T, name string, src []byte) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skipf("go command not found, skip the compile check")
+	}
+
+	dir, err := ioutil.TempDir("testdata", "compile")
+	if err != nil {
+		t.Logf("temp dir %v", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "models.go"), src, 0644); err != nil {
+		t.Logf("write %v", err)
+		t.FailNow()
+	}
+
+	if test, err := ioutil.ReadFile(strings.TrimSuffix(name, ".cql") + "_test.go.in"); err == nil {
+		if err := ioutil.WriteFile(filepath.Join(dir, "models_test.go"), test, 0644); err != nil {
+			t.Logf("write %v", err)
+			t.FailNow()
+		}
+	}
+
+	out, err := exec.Command(goBin, "test", "./"+filepath.ToSlash(dir)).CombinedOutput()
+	if err != nil {
+		t.Logf("generated code of %s fails: %v\n%s", name, err, out)
+		t.FailNow()
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	ks, err := cb.ParseSchema("CREATE TABLE a.t (id int PRIMARY KEY); CREATE TABLE b.t (id int PRIMARY KEY);")
+	if err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if _, err := generate(&genConfig{Package: "models", Import: "cqlbuilder"}, ks.Tables); err == nil {
+		t.Logf("err expected for the same go name")
+		t.FailNow()
+	}
+}
+
+func TestCqlName(t *testing.T) {
+	cases := map[string]string{
+		"org_id":      "org_id",
+		"displayName": `"displayName"`,
+		`a"B`:         `"a""B"`,
+	}
+	for s, expected := range cases {
+		if name := cqlName(s); name != expected {
+			t.Logf("name of %s is %s", s, name)
+			t.FailNow()
+		}
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	cases := map[string]string{
+		"org_id":      "OrgId",
+		"displayName": "DisplayName",
+		"2fa":         "X2fa",
+		"a__b":        "AB",
+	}
+	for s, expected := range cases {
+		if name := exportedName(s); name != expected {
+			t.Logf("name of %s is %s", s, name)
+			t.FailNow()
+		}
+	}
+}
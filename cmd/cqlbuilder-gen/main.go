@@ -0,0 +1,82 @@
+// The cqlbuilder-gen command generates the typed table accessors from the
+// CREATE TABLE statements of the CQL scripts: the table and column name
+// constants, the row struct with cql tags, and the helpers returning the
+// select/insert/update/delete builders of the primary key.
+// Usage:
+//
+//	cqlbuilder-gen -pkg models -o tables_gen.go schema.cql ...
+//
+// or with go generate:
+//
+//	//go:generate cqlbuilder-gen -pkg models -o tables_gen.go schema.cql
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	cb "cqlbuilder"
+)
+
+func main() {
+	var (
+		pkg    = flag.String("pkg", "models", "the package name of the generated code")
+		out    = flag.String("o", "", "the output file, default is stdout")
+		imp    = flag.String("import", "cqlbuilder", "the import path of the cqlbuilder package")
+		tables = flag.String("tables", "", "the comma separated tables to generate, default is all")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: cqlbuilder-gen [flags] schema.cql ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*pkg, *out, *imp, *tables, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "cqlbuilder-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(pkg, out, imp, tables string, files []string) error {
+	var schemas []*cb.TableSchema
+	for _, f := range files {
+		ks, err := cb.LoadSchemaFile(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+		schemas = append(schemas, ks.Tables...)
+	}
+
+	if len(tables) > 0 {
+		wanted := map[string]bool{}
+		for _, t := range strings.Split(tables, ",") {
+			wanted[strings.TrimSpace(t)] = true
+		}
+		selected := schemas[:0]
+		for _, s := range schemas {
+			if wanted[s.Name] || wanted[s.Keyspace+"."+s.Name] {
+				selected = append(selected, s)
+			}
+		}
+		schemas = selected
+	}
+
+	src, err := generate(&genConfig{Package: pkg, Import: imp, Source: files}, schemas)
+	if err != nil {
+		return err
+	}
+
+	if len(out) == 0 {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
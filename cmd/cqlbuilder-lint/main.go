@@ -0,0 +1,104 @@
+// The cqlbuilder-lint command lints the queries registered by
+// cqlbuilder.RegisterQuery/RegisterBatch in the packages. The queries live
+// in the application, so the command builds the main importing the
+// packages in the current module and runs cqlbuilder.LintCommand in it.
+// The flags after -- are passed to LintCommand, the exit code is its one.
+// Usage:
+//
+//	cqlbuilder-lint myapp/queries ... -- -schema schema.cql -expiring sessions -json
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+func main() {
+	imp := flag.String("import", "cqlbuilder", "the import path of the cqlbuilder package")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: cqlbuilder-lint [flags] package ... [-- lint flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	pkgs, args := splitArgs(flag.Args())
+	if len(pkgs) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	code, err := run(*imp, pkgs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cqlbuilder-lint:", err)
+		os.Exit(2)
+	}
+	os.Exit(code)
+}
+
+// Split the args into the packages and the flags of LintCommand after --.
+func splitArgs(args []string) ([]string, []string) {
+	for i, a := range args {
+		if a == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// Build and run the lint main of the packages, return its exit code.
+func run(imp string, pkgs, args []string) (int, error) {
+	src, err := mainSource(imp, pkgs)
+	if err != nil {
+		return 0, err
+	}
+
+	// The main must be in the module to import the packages.
+	dir, err := ioutil.TempDir(".", "cqlbuilder-lint")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), src, 0644); err != nil {
+		return 0, err
+	}
+
+	bin, err := filepath.Abs(filepath.Join(dir, "lint"))
+	if err != nil {
+		return 0, err
+	}
+	build := exec.Command("go", "build", "-o", bin, "./"+filepath.ToSlash(dir))
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		return 0, fmt.Errorf("build the lint of %v: %w", pkgs, err)
+	}
+
+	lint := exec.Command(bin, args...)
+	lint.Stdin, lint.Stdout, lint.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = lint.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// The source of the lint main importing the packages.
+func mainSource(imp string, pkgs []string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by cqlbuilder-lint. DO NOT EDIT.\n\npackage main\n\nimport (\n\"os\"\n\n")
+	fmt.Fprintf(&buf, "cb %s\n", strconv.Quote(imp))
+	for _, p := range pkgs {
+		fmt.Fprintf(&buf, "_ %s\n", strconv.Quote(p))
+	}
+	buf.WriteString(")\n\nfunc main() {\nos.Exit(cb.LintCommand(os.Args[1:], os.Stdout))\n}\n")
+	return format.Source(buf.Bytes())
+}
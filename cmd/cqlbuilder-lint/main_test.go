@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	pkgs, args := splitArgs([]string{"a/b", "c", "--", "-json"})
+	if len(pkgs) != 2 || len(args) != 1 || args[0] != "-json" {
+		t.Logf("pkgs %v args %v", pkgs, args)
+		t.FailNow()
+	}
+
+	if pkgs, args = splitArgs([]string{"a/b"}); len(pkgs) != 1 || args != nil {
+		t.Logf("pkgs %v args %v", pkgs, args)
+		t.FailNow()
+	}
+}
+
+func TestMainSource(t *testing.T) {
+	src, err := mainSource("cqlbuilder", []string{"myapp/queries"})
+	if err != nil || !strings.Contains(string(src), `_ "myapp/queries"`) || !strings.Contains(string(src), "cb.LintCommand(os.Args[1:], os.Stdout)") {
+		t.Logf("src %s err %v", src, err)
+		t.FailNow()
+	}
+}
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skipf("go command not found")
+	}
+
+	pkgs := []string{"cqlbuilder/cmd/cqlbuilder-lint/testdata/queries"}
+
+	// users.all has no LIMIT.
+	if code, err := run("cqlbuilder", pkgs, []string{"-schema", "testdata/schema.cql"}); err != nil || code != 1 {
+		t.Logf("code %d err %v", code, err)
+		t.FailNow()
+	}
+
+	if code, err := run("cqlbuilder", pkgs, []string{"-schema", "testdata/schema.cql", "-fail", "error"}); err != nil || code != 0 {
+		t.Logf("code %d err %v", code, err)
+		t.FailNow()
+	}
+}
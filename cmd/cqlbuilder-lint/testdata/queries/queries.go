@@ -0,0 +1,11 @@
+// The queries linted by the test of cqlbuilder-lint.
+package queries
+
+import (
+	cb "cqlbuilder"
+)
+
+func init() {
+	cb.RegisterQuery("users.get", cb.Select("app.users").AddColumn("name").Where(cb.Eq("org_id", "o")).Where(cb.Eq("id", 1)))
+	cb.RegisterQuery("users.all", cb.Select("app.users").AddColumn("name").Where(cb.Eq("org_id", "o")))
+}
@@ -3,10 +3,11 @@ package cqlbuilder
 import (
 	"bytes"
 	"fmt"
+	"strings"
 )
 
 type conditionBuilder interface {
-	toCondition() (string, []interface{})
+	toCondition() (string, []interface{}, error)
 }
 
 // Create eq condition builder
@@ -24,6 +25,31 @@ func In(col string, vs interface{}) conditionBuilder {
 	}
 }
 
+// Create column > value condition builder.
+func Gt(column string, value interface{}) conditionBuilder {
+	return &cmpBuilder{columns: []string{column}, op: ">", values: []interface{}{value}}
+}
+
+// Create column >= value condition builder.
+func Ge(column string, value interface{}) conditionBuilder {
+	return &cmpBuilder{columns: []string{column}, op: ">=", values: []interface{}{value}}
+}
+
+// Create column < value condition builder.
+func Lt(column string, value interface{}) conditionBuilder {
+	return &cmpBuilder{columns: []string{column}, op: "<", values: []interface{}{value}}
+}
+
+// Create column <= value condition builder.
+func Le(column string, value interface{}) conditionBuilder {
+	return &cmpBuilder{columns: []string{column}, op: "<=", values: []interface{}{value}}
+}
+
+// Create column IS NOT NULL condition builder, used by the materialized view.
+func IsNotNull(column string) conditionBuilder {
+	return &notNullBuilder{column: column}
+}
+
 // Create exists condition builder.
 func Exists() conditionBuilder {
 	return &existsBuilder{}
@@ -31,11 +57,15 @@ func Exists() conditionBuilder {
 
 // string sth like EXISTS AND version=? AND name=?
 // values :  1, "test"
-func buildCondition(conditions []conditionBuilder) (string, []interface{}) {
+// The error is returned if any column name is invalid.
+func buildCondition(conditions []conditionBuilder) (string, []interface{}, error) {
 	var condition bytes.Buffer
 	values := make([]interface{}, 0, len(conditions))
 	for i, c := range conditions {
-		clause, v := c.toCondition()
+		clause, v, err := c.toCondition()
+		if err != nil {
+			return "", nil, err
+		}
 		condition.WriteString(clause)
 		// The last condition don't need and
 		if i != len(conditions)-1 {
@@ -46,7 +76,7 @@ func buildCondition(conditions []conditionBuilder) (string, []interface{}) {
 		}
 	}
 
-	return condition.String(), values
+	return condition.String(), values, nil
 }
 
 type inBuilder struct {
@@ -54,9 +84,13 @@ type inBuilder struct {
 	values  interface{}
 }
 
-func (i *inBuilder) toCondition() (string, []interface{}) {
-	c := fmt.Sprintf("%s in ?", i.column)
-	return c, []interface{}{i.values}
+func (i *inBuilder) toCondition() (string, []interface{}, error) {
+	col, err := quoteColumn(i.column)
+	if err != nil {
+		return "", nil, err
+	}
+	c := fmt.Sprintf("%s in ?", col)
+	return c, []interface{}{i.values}, nil
 }
 
 
@@ -65,15 +99,55 @@ type eqBuilder struct {
 	value  interface{}
 }
 
-func (eq *eqBuilder) toCondition() (string, []interface{}) {
-	c := fmt.Sprintf("%s=?", eq.column)
-	return c, []interface{}{eq.value}
+func (eq *eqBuilder) toCondition() (string, []interface{}, error) {
+	col, err := quoteColumn(eq.column)
+	if err != nil {
+		return "", nil, err
+	}
+	c := fmt.Sprintf("%s=?", col)
+	return c, []interface{}{eq.value}, nil
+}
+
+// The comparison of a column or a tuple of columns(multi-column slice
+// restriction of clustering columns), sth like (c1,c2)>(?,?)
+type cmpBuilder struct {
+	columns []string
+	op      string
+	values  []interface{}
+}
+
+func (c *cmpBuilder) toCondition() (string, []interface{}, error) {
+	columns, err := quoteColumns(c.columns)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(columns) == 1 {
+		return fmt.Sprintf("%s%s?", columns[0], c.op), c.values, nil
+	}
+
+	marks := strings.TrimSuffix(strings.Repeat(questMak+comma, len(columns)), comma)
+	cond := fmt.Sprintf("(%s)%s(%s)", strings.Join(columns, comma), c.op, marks)
+	return cond, c.values, nil
 }
 
 // To build EXISTS which used if clause of delete/update.
 type existsBuilder struct {
 }
 
-func (*existsBuilder) toCondition() (string, []interface{}) {
-	return exists, nil
+func (*existsBuilder) toCondition() (string, []interface{}, error) {
+	return exists, nil, nil
+}
+
+// To build IS NOT NULL which used in where clause of materialized view.
+type notNullBuilder struct {
+	column string
+}
+
+func (n *notNullBuilder) toCondition() (string, []interface{}, error) {
+	col, err := quoteColumn(n.column)
+	if err != nil {
+		return "", nil, err
+	}
+	return col + " IS NOT NULL", nil, nil
 }
@@ -1,7 +1,9 @@
 package cqlbuilder
 
 import (
+	"context"
 	"errors"
+	"strings"
 
 	cql "github.com/gocql/gocql"
 )
@@ -29,6 +31,9 @@ const (
 	from        = " FROM "
 	selectKW    = " SELECT "
 	limit       = " LIMIT "
+	orderBy     = " ORDER BY "
+	asc         = " ASC"
+	desc        = " DESC"
 
 	allowFiltering = " ALLOW FILTERING "
 )
@@ -44,6 +49,53 @@ type CqlBuilder interface {
 	ToQuery() (string, []interface{}, error)
 }
 
+// The kind of statement a builder produces.
+type StatementKind string
+
+const (
+	KindInsert  StatementKind = "INSERT"
+	KindUpdate  StatementKind = "UPDATE"
+	KindDelete  StatementKind = "DELETE"
+	KindSelect  StatementKind = "SELECT"
+	KindBatch   StatementKind = "BATCH"
+	KindSchema  StatementKind = "SCHEMA"
+	KindUnknown StatementKind = "UNKNOWN"
+)
+
+// The builder knows the table and the kind of the statement it builds.
+type statementInfo interface {
+	statementTable() string
+	statementKind() StatementKind
+}
+
+// Get the table and kind of the builder. The table of a batch is the
+// distinct tables of its statements joined by comma.
+func describeStatement(c interface{}) (string, StatementKind) {
+	if b, ok := c.(*BatchBuilder); ok {
+		tables := make([]string, 0, len(b.builders))
+		seen := map[string]bool{}
+		for _, q := range b.builders {
+			t, _ := describeStatement(q)
+			if !seen[t] {
+				seen[t] = true
+				tables = append(tables, t)
+			}
+		}
+		return strings.Join(tables, comma), KindBatch
+	}
+
+	if si, ok := c.(statementInfo); ok {
+		return si.statementTable(), si.statementKind()
+	}
+	return "", KindUnknown
+}
+
+// Get the table and kind of the builder or batch, for the test helpers and
+// the wrappers of ExecManager.
+func DescribeStatement(c interface{}) (table string, kind StatementKind) {
+	return describeStatement(c)
+}
+
 //Create insert builder
 func Insert(t string) *InsertBuilder {
 	ret := InsertBuilder{
@@ -82,83 +134,71 @@ func StartBatch() *BatchBuilder {
 
 // Exec the single statement
 func Exec(c CqlBuilder, session *cql.Session) error {
-	str, vals, err := c.ToQuery()
-
-	if err != nil {
-		return err
-	}
+	return ExecContext(context.Background(), c, session)
+}
 
-	err = session.Query(str, vals...).Exec()
-	return err
+// Exec the single statement with context.
+func ExecContext(ctx context.Context, c CqlBuilder, session *cql.Session) error {
+	return execStatement(ctx, c, NewGocqlSession(session))
 }
 
 // Exec the batch
 func ExecBatch(b *BatchBuilder, session *cql.Session) error {
-	batch := session.NewBatch(cql.LoggedBatch)
-	for _, q := range b.builders {
-		str, vals, err := q.ToQuery()
-		if err != nil {
-			return err
-		}
-
-		batch.Query(str, vals...)
-	}
+	return ExecBatchContext(context.Background(), b, session)
+}
 
-	err := session.ExecuteBatch(batch)
-	return err
+// Exec the batch with context.
+func ExecBatchContext(ctx context.Context, b *BatchBuilder, session *cql.Session) error {
+	return execBatch(ctx, b, NewGocqlSession(session))
 }
 
 //Exec query CAS
 func ExecCAS(c CqlBuilder, s *cql.Session, dest ...interface{}) (bool, error) {
+	return ExecCASContext(context.Background(), c, s, dest...)
+}
 
-	cqlstr, vals, err := c.ToQuery()
-	if err != nil {
-		return false, err
-	}
-	q := s.Query(cqlstr, vals...)
-	applied, err := q.ScanCAS(dest...)
-
-	return applied, err
+//Exec query CAS with context.
+func ExecCASContext(ctx context.Context, c CqlBuilder, s *cql.Session, dest ...interface{}) (bool, error) {
+	return execCAS(ctx, c, NewGocqlSession(s), dest...)
 }
 
 // Return result set
 func Iter(c CqlBuilder, s *cql.Session, des ...interface{}) (*cql.Iter, error) {
-	cqlstr, vals, err := c.ToQuery()
+	return IterContext(context.Background(), c, s, des...)
+}
+
+// Return result set, the query is bound to the context.
+func IterContext(ctx context.Context, c CqlBuilder, s *cql.Session, des ...interface{}) (*cql.Iter, error) {
+	iter, err := iterRows(ctx, c, NewGocqlSession(s))
 	if err != nil {
 		return nil, err
 	}
-	q := s.Query(cqlstr, vals...)
 
-	return q.Iter(), nil
+	return toGocqlIter(iter)
 }
 
 //Batch CAS
 func ExecBatchCAS(b *BatchBuilder, session *cql.Session, dest ...interface{}) (applied bool, iter *cql.Iter, err error) {
-	batch := session.NewBatch(cql.LoggedBatch)
-	for _, q := range b.builders {
-		str, vals, err := q.ToQuery()
-		if err != nil {
-			return false, nil, err
-		}
+	return ExecBatchCASContext(context.Background(), b, session, dest...)
+}
 
-		batch.Query(str, vals...)
+//Batch CAS with context.
+func ExecBatchCASContext(ctx context.Context, b *BatchBuilder, session *cql.Session, dest ...interface{}) (applied bool, iter *cql.Iter, err error) {
+	applied, rows, err := execBatchCAS(ctx, b, NewGocqlSession(session), dest...)
+	if err != nil {
+		return applied, nil, err
 	}
 
-	applied, iter, err = session.ExecuteBatchCAS(batch, dest...)
-
+	iter, err = toGocqlIter(rows)
 	return applied, iter, err
 }
 
 // run a query and fill back the result.
 func ExecScan(c CqlBuilder, s *cql.Session, dest ...interface{}) error {
-	cqlstr, vals, err := c.ToQuery()
-	if err != nil {
-		return err
-	}
-
-	q := s.Query(cqlstr, vals...)
-
-	err = q.Scan(dest...)
+	return ExecScanContext(context.Background(), c, s, dest...)
+}
 
-	return err
+// run a query with context and fill back the result.
+func ExecScanContext(ctx context.Context, c CqlBuilder, s *cql.Session, dest ...interface{}) error {
+	return execScan(ctx, c, NewGocqlSession(s), dest...)
 }
@@ -1,6 +1,8 @@
 package cqlbuilder
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -237,3 +239,441 @@ func SampleCode(t *testing.T) {
 		fmt.Println(err)
 	}
 }
+
+func TestQueryOptions(t *testing.T) {
+	sel := Select("test").AddColumn("col1").Where(Eq("col2", 1)).SetOptions(Options().Consistency(cql.Quorum).Idempotent(true))
+
+	q := optionsOf(sel).applyQuery(&gocqlQuery{q: &cql.Query{}}).(*gocqlQuery).q
+	if q.GetConsistency() != cql.Quorum || !q.IsIdempotent() {
+		t.Logf("options not applied %v %v", q.GetConsistency(), q.IsIdempotent())
+		t.FailNow()
+	}
+
+	// No options attached, keep the default.
+	q = optionsOf(Select("test")).applyQuery(&gocqlQuery{q: &cql.Query{}}).(*gocqlQuery).q
+	if q.GetConsistency() != cql.Any || q.IsIdempotent() {
+		t.Logf("unexpected options applied %v %v", q.GetConsistency(), q.IsIdempotent())
+		t.FailNow()
+	}
+
+	batch := StartBatch().SetOptions(Options().Consistency(cql.LocalQuorum))
+	b := optionsOf(batch).applyBatch(&gocqlBatch{b: &cql.Batch{}}).(*gocqlBatch).b
+	if b.GetConsistency() != cql.LocalQuorum {
+		t.Logf("batch options not applied %v", b.GetConsistency())
+		t.FailNow()
+	}
+}
+
+type recordObserver struct {
+	events []*ExecEvent
+}
+
+func (r *recordObserver) ObserveExec(ctx context.Context, e *ExecEvent) {
+	r.events = append(r.events, e)
+}
+
+func TestObserver(t *testing.T) {
+	obs := &recordObserver{}
+	em := &SessionExecManager{Observer: obs}
+
+	// The builder fails to build, so the session is never touched.
+	err := em.Exec(Update("test").SetValue("col1", 1))
+	if err == nil || len(obs.events) != 1 {
+		t.Logf("err %v events %v", err, obs.events)
+		t.FailNow()
+	}
+
+	e := obs.events[0]
+	if e.Op != OpExec || e.Kind != KindUpdate || e.Table != "test" || e.Err != err {
+		t.Logf("unexpected event %+v", e)
+		t.FailNow()
+	}
+
+	// The error of the read is observed at Close.
+	readErr := errors.New("read timeout")
+	em.Driver = &recordSession{iterErr: readErr}
+	iter, err := em.IterRows(Select("test").AddColumn("col1").Where(Eq("col2", 2)))
+	if err != nil || len(obs.events) != 1 {
+		t.Logf("err %v events %v", err, obs.events)
+		t.FailNow()
+	}
+	iter.Close()
+	iter.Close()
+	if len(obs.events) != 2 || obs.events[1].Op != OpIter || obs.events[1].Err != readErr {
+		t.Logf("events %v", obs.events)
+		t.FailNow()
+	}
+}
+
+func TestStatementTextOfBatch(t *testing.T) {
+	batch := StartBatch().Add(Insert("t1").SetValue("c1", "secret")).Add(Delete("t2").Where(Eq("c1", 1)))
+
+	table, kind := describeStatement(batch)
+	str, vals := statementText(batch)
+	if table != "t1,t2" || kind != KindBatch || !strings.HasPrefix(str, "BEGIN BATCH ") || len(vals) != 2 {
+		t.Logf("table %s kind %s str %s vals %v", table, kind, str, vals)
+		t.FailNow()
+	}
+}
+
+// The stub exec manager returns the preset result for every call.
+type stubExecManager struct {
+	applied bool
+	err     error
+}
+
+func (s *stubExecManager) Exec(c CqlBuilder) error                          { return s.err }
+func (s *stubExecManager) ExecBatch(b *BatchBuilder) error                  { return s.err }
+func (s *stubExecManager) ExecScan(c CqlBuilder, dest ...interface{}) error { return s.err }
+func (s *stubExecManager) ExecCAS(c CqlBuilder, dest ...interface{}) (bool, error) {
+	return s.applied, s.err
+}
+func (s *stubExecManager) ExecBatchCAS(b *BatchBuilder, dest ...interface{}) (bool, *cql.Iter, error) {
+	return s.applied, nil, s.err
+}
+func (s *stubExecManager) Iter(c CqlBuilder, des ...interface{}) (*cql.Iter, error) {
+	return nil, s.err
+}
+
+func TestCategorizeError(t *testing.T) {
+	cases := map[error]ErrorCategory{
+		cql.ErrNotFound: ErrCategoryNotFound,
+		fmt.Errorf("migration 1 step 0: %w", context.DeadlineExceeded):                 ErrCategoryTimeout,
+		fmt.Errorf("step: %w", &cql.RequestErrUnavailable{}):                           ErrCategoryUnavailable,
+		fmt.Errorf("step: %w", &SchemaError{Statement: "CREATE TABLE", Reason: "bad"}): ErrCategoryInvalid,
+		errors.New("boom"): ErrCategoryOther,
+	}
+	for err, expected := range cases {
+		if c := CategorizeError(err); c != expected {
+			t.Logf("category of %v is %s, expected %s", err, c, expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestInstrumentedExecManager(t *testing.T) {
+	m := &MemoryMetrics{}
+	stub := &stubExecManager{}
+	mgr := &InstrumentedExecManager{Next: stub, Metrics: m}
+	key := MetricKey{Table: "test", Kind: KindUpdate}
+
+	up := Update("test").SetValue("col1", 1).Where(Eq("col2", 2))
+	mgr.Exec(up)
+	mgr.ExecCAS(up.If(Eq("col1", 0)))
+	stub.applied = true
+	mgr.ExecCAS(up)
+	stub.err = cql.ErrTimeoutNoResponse
+	mgr.Exec(up)
+
+	applied, notApplied := m.CAS(key)
+	if m.Count(key) != 4 || applied != 1 || notApplied != 1 || m.Errors(key, ErrCategoryTimeout) != 1 {
+		t.Logf("count %d applied %d notApplied %d errors %d", m.Count(key), applied, notApplied, m.Errors(key, ErrCategoryTimeout))
+		t.FailNow()
+	}
+
+	total := 0
+	for _, n := range m.Histogram(key) {
+		total += n
+	}
+	if total != 4 {
+		t.Logf("histogram %v", m.Histogram(key))
+		t.FailNow()
+	}
+
+	// The error of the read is recorded at Close.
+	driver := &recordSession{iterErr: cql.ErrTimeoutNoResponse}
+	mgr.Next = &SessionExecManager{Driver: driver}
+	selKey := MetricKey{Table: "test", Kind: KindSelect}
+	iter, err := mgr.IterRows(Select("test").AddColumn("col1").Where(Eq("col2", 2)))
+	if err != nil || m.Count(selKey) != 0 {
+		t.Logf("err %v count %d", err, m.Count(selKey))
+		t.FailNow()
+	}
+	iter.Close()
+	iter.Close()
+	if m.Count(selKey) != 1 || m.Errors(selKey, ErrCategoryTimeout) != 1 {
+		t.Logf("count %d errors %d", m.Count(selKey), m.Errors(selKey, ErrCategoryTimeout))
+		t.FailNow()
+	}
+
+	// The columns are read through the wrapped iterator.
+	driver.rows = NewSliceIterator([]string{"name", "id"}, []interface{}{"a", int64(1)})
+	var rows []testRow
+	if err := mgr.IterAll(Select("test").Where(Eq("id", 1)), &rows); err != nil || len(rows) != 1 || rows[0].Name != "a" {
+		t.Logf("err %v rows %v", err, rows)
+		t.FailNow()
+	}
+}
+
+func TestSliceIterator(t *testing.T) {
+	iter := NewSliceIterator([]string{"id", "name"}, []interface{}{1, "a"}, []interface{}{int64(2), "b"})
+
+	var ids []int64
+	var names []string
+	var id int64
+	var name string
+	for iter.Scan(&id, &name) {
+		ids = append(ids, id)
+		names = append(names, name)
+	}
+	if err := iter.Close(); err != nil || len(ids) != 2 || ids[1] != 2 || names[0] != "a" {
+		t.Logf("err %v ids %v names %v", err, ids, names)
+		t.FailNow()
+	}
+
+	iter = NewSliceIterator([]string{"id"}, []interface{}{"not a number"})
+	if iter.Scan(&id) || iter.Close() == nil {
+		t.Logf("Err expected if scan string into int64")
+		t.FailNow()
+	}
+
+	var small int8
+	var u uint32
+	var f32 float32
+	var f64 float64
+	fits := []struct {
+		dest interface{}
+		v    interface{}
+	}{{&small, int64(-128)}, {&u, int64(7)}, {&id, 3.0}, {&f32, 0.5}, {&f64, int64(1) << 52}}
+	for _, c := range fits {
+		if err := assignValue(c.dest, c.v); err != nil {
+			t.Logf("assign %v err %v", c.v, err)
+			t.FailNow()
+		}
+	}
+	lossy := []struct {
+		dest interface{}
+		v    interface{}
+	}{{&small, int64(300)}, {&u, -1}, {&u, uint64(1) << 40}, {&id, 1.5}, {&id, uint64(1) << 63}, {&f32, 0.1}, {&f64, int64(1)<<53 + 1}}
+	for _, c := range lossy {
+		if err := assignValue(c.dest, c.v); err == nil {
+			t.Logf("err expected if assign %v(%T) into %T", c.v, c.v, c.dest)
+			t.FailNow()
+		}
+	}
+
+	iter = NewSliceIterator([]string{"id", "name"}, []interface{}{1, "a"})
+	m := map[string]interface{}{}
+	if !iter.MapScan(m) || m["name"] != "a" || iter.NumRows() != 1 {
+		t.Logf("map %v", m)
+		t.FailNow()
+	}
+}
+
+// The driver records the statements, the contexts and the options applied.
+// The statement of the canceled context fails like gocql.
+type recordSession struct {
+	stmts []string
+	ctxs  []context.Context
+	cons  []cql.Consistency
+	// The error of the iterator returned by Iter.
+	iterErr error
+	// The rows returned by Iter, empty if nil.
+	rows *SliceIterator
+	// Iter returns nil.
+	nilIter bool
+}
+
+type recordQuery struct {
+	s    *recordSession
+	stmt string
+	ctx  context.Context
+}
+
+type recordBatch struct {
+	s     *recordSession
+	stmts []string
+	ctx   context.Context
+}
+
+func (r *recordSession) Query(stmt string, values ...interface{}) SessionQuery {
+	return &recordQuery{s: r, stmt: stmt, ctx: context.Background()}
+}
+func (r *recordSession) NewBatch(typ cql.BatchType) SessionBatch {
+	return &recordBatch{s: r, ctx: context.Background()}
+}
+func (r *recordSession) ExecuteBatch(b SessionBatch) error {
+	rb := b.(*recordBatch)
+	if err := rb.ctx.Err(); err != nil {
+		return err
+	}
+	r.stmts = append(r.stmts, rb.stmts...)
+	return nil
+}
+func (r *recordSession) ExecuteBatchCAS(b SessionBatch, dest ...interface{}) (bool, RowIterator, error) {
+	return false, nil, r.ExecuteBatch(b)
+}
+
+func (b *recordBatch) WithContext(ctx context.Context) SessionBatch {
+	b.ctx = ctx
+	b.s.ctxs = append(b.s.ctxs, ctx)
+	return b
+}
+func (b *recordBatch) Query(stmt string, values ...interface{}) { b.stmts = append(b.stmts, stmt) }
+func (b *recordBatch) Consistency(c cql.Consistency) SessionBatch {
+	b.s.cons = append(b.s.cons, c)
+	return b
+}
+func (b *recordBatch) SerialConsistency(c cql.SerialConsistency) SessionBatch { return b }
+func (b *recordBatch) Idempotent(i bool) SessionBatch                         { return b }
+func (b *recordBatch) RetryPolicy(r cql.RetryPolicy) SessionBatch             { return b }
+func (b *recordBatch) DefaultTimestamp(enable bool) SessionBatch              { return b }
+func (b *recordBatch) WithTimestamp(ts int64) SessionBatch                    { return b }
+func (b *recordBatch) Trace(t cql.Tracer) SessionBatch                        { return b }
+
+func (q *recordQuery) WithContext(ctx context.Context) SessionQuery {
+	q.ctx = ctx
+	q.s.ctxs = append(q.s.ctxs, ctx)
+	return q
+}
+func (q *recordQuery) Consistency(c cql.Consistency) SessionQuery {
+	q.s.cons = append(q.s.cons, c)
+	return q
+}
+func (q *recordQuery) SerialConsistency(c cql.SerialConsistency) SessionQuery { return q }
+func (q *recordQuery) PageSize(n int) SessionQuery                            { return q }
+func (q *recordQuery) PageState(state []byte) SessionQuery                    { return q }
+func (q *recordQuery) Idempotent(i bool) SessionQuery                         { return q }
+func (q *recordQuery) RetryPolicy(r cql.RetryPolicy) SessionQuery             { return q }
+func (q *recordQuery) DefaultTimestamp(enable bool) SessionQuery              { return q }
+func (q *recordQuery) WithTimestamp(ts int64) SessionQuery                    { return q }
+func (q *recordQuery) Trace(t cql.Tracer) SessionQuery                        { return q }
+func (q *recordQuery) Exec() error {
+	if err := q.ctx.Err(); err != nil {
+		return err
+	}
+	q.s.stmts = append(q.s.stmts, q.stmt)
+	return nil
+}
+func (q *recordQuery) Scan(dest ...interface{}) error            { return q.Exec() }
+func (q *recordQuery) ScanCAS(dest ...interface{}) (bool, error) { return true, q.Exec() }
+func (q *recordQuery) Iter() RowIterator {
+	if q.s.nilIter {
+		return nil
+	}
+	if err := q.ctx.Err(); err != nil {
+		return &SliceIterator{Err: err}
+	}
+	if q.s.rows != nil {
+		return q.s.rows
+	}
+	return &SliceIterator{Err: q.s.iterErr}
+}
+
+func TestSessionExecManagerDriver(t *testing.T) {
+	driver := &recordSession{}
+	em := &SessionExecManager{Driver: driver}
+
+	up := Update("test").SetValue("col1", 1).Where(Eq("col2", 2)).SetOptions(Options().Consistency(cql.Quorum))
+	if err := em.Exec(up); err != nil || len(driver.stmts) != 1 || driver.cons[0] != cql.Quorum {
+		t.Logf("err %v stmts %v cons %v", err, driver.stmts, driver.cons)
+		t.FailNow()
+	}
+
+	// The driver can't give *cql.Iter.
+	if _, err := em.Iter(Select("test").AddColumn("col1").Where(Eq("col2", 2))); err != ErrDriverIterNotSupported {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	// The driver gives no iterator.
+	driver.nilIter = true
+	if _, err := em.Iter(Select("test").AddColumn("col1").Where(Eq("col2", 2))); err != ErrPreparingQueryFailed {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if _, err := em.IterRows(Select("test").AddColumn("col1").Where(Eq("col2", 2))); err != ErrPreparingQueryFailed {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	driver.nilIter = false
+
+	// The package level functions of the driver.
+	if err := ExecDriver(up, driver); err != nil || len(driver.stmts) != 2 {
+		t.Logf("err %v stmts %v", err, driver.stmts)
+		t.FailNow()
+	}
+	if _, err := ExecCASDriver(up, driver); err != nil || len(driver.stmts) != 3 {
+		t.Logf("err %v stmts %v", err, driver.stmts)
+		t.FailNow()
+	}
+	iter, err := IterRowsDriver(Select("test").AddColumn("col1").Where(Eq("col2", 2)), driver)
+	if err != nil || iter == nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+}
+
+type ctxKey struct{}
+
+func TestExecContext(t *testing.T) {
+	driver := &recordSession{}
+	em := &SessionExecManager{Driver: driver}
+	up := Update("test").SetValue("col1", 1).Where(Eq("col2", 2))
+	sel := Select("test").AddColumn("col1").Where(Eq("col2", 2))
+	batch := StartBatch().Add(up).Add(Delete("test").Where(Eq("col2", 3)))
+
+	// The ctx reaches the query and the batch.
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	if err := em.ExecContext(ctx, up); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if err := em.ExecBatchContext(ctx, batch); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	iter, err := em.IterRowsContext(ctx, sel)
+	if err != nil || iter.Close() != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if err := ExecDriverContext(ctx, up, driver); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	for _, c := range driver.ctxs {
+		if c.Value(ctxKey{}) != "v" {
+			t.Logf("ctx %v", driver.ctxs)
+			t.FailNow()
+		}
+	}
+	if len(driver.ctxs) != 4 || len(driver.stmts) != 4 {
+		t.Logf("ctxs %v stmts %v", driver.ctxs, driver.stmts)
+		t.FailNow()
+	}
+
+	// The canceled ctx aborts the exec, the iteration and the batch.
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := em.ExecContext(ctx, up); err != context.Canceled {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if err := em.ExecBatchContext(ctx, batch); err != context.Canceled {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if _, _, err := em.ExecBatchCASRowsContext(ctx, batch); err != context.Canceled {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	iter, err = em.IterRowsContext(ctx, sel)
+	if err != nil || iter.Scan() || iter.Close() != context.Canceled {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if len(driver.stmts) != 4 {
+		t.Logf("stmts %v", driver.stmts)
+		t.FailNow()
+	}
+}
+
+func TestUpdateWithTTL(t *testing.T) {
+	str, vals, err := Update("test").SetValue("col1", 123).Where(Eq("col2", 1)).SetTtl(10).ToQuery()
+
+	if strings.Trim(strings.ToLower(str), " ") != strings.ToLower("UPDATE test USING  TTL ?  SET col1 =?  WHERE col2=?") || len(vals) != 3 || vals[0] != 10 || err != nil {
+		t.Logf("str %s  vals %v", str, vals)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,347 @@
+// Package cqlbuildertest provides the test helpers of cqlbuilder, kept out
+// of the root package so the binaries linking cqlbuilder don't link the
+// testing package.
+package cqlbuildertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	cb "cqlbuilder"
+	cql "github.com/gocql/gocql"
+)
+
+var ErrMockUnexpectedCall = errors.New("cqlbuildertest: unexpected call to mock exec manager")
+
+var (
+	_ cb.ExecManager        = (*MockExecManager)(nil)
+	_ cb.ExecManagerV2      = (*MockExecManager)(nil)
+	_ cb.ExecManagerContext = (*MockExecManager)(nil)
+	_ cb.StructExecManager  = (*MockExecManager)(nil)
+)
+
+// The call recorded by MockExecManager.
+type MockRecord struct {
+	Op    cb.ExecOp
+	Kind  cb.StatementKind
+	Table string
+	// The CQL text and values, for batch see ExecEvent.Statement.
+	Statement string
+	Values    []interface{}
+}
+
+// The expectation of MockExecManager, the unset fields match anything.
+type MockCall struct {
+	op        cb.ExecOp
+	kind      cb.StatementKind
+	table     string
+	statement string
+	values    []interface{}
+	hasValues bool
+
+	err     error
+	applied bool
+	rows    *cb.SliceIterator
+
+	times int
+	calls int
+}
+
+// Expect the statement kind.
+func (m *MockCall) Kind(k cb.StatementKind) *MockCall {
+	m.kind = k
+	return m
+}
+
+// Expect the table.
+func (m *MockCall) Table(t string) *MockCall {
+	m.table = t
+	return m
+}
+
+// Expect the CQL text.
+func (m *MockCall) CQL(s string) *MockCall {
+	m.statement = s
+	return m
+}
+
+// Expect the bound values.
+func (m *MockCall) Values(vs ...interface{}) *MockCall {
+	m.values = vs
+	m.hasValues = true
+	return m
+}
+
+// Expect exactly the statement(CQL text and values) the builder build.
+func (m *MockCall) Statement(c interface{}) *MockCall {
+	m.table, m.kind = cb.DescribeStatement(c)
+	m.statement, m.values = cb.StatementText(c)
+	m.hasValues = true
+	return m
+}
+
+// Return the error.
+func (m *MockCall) Return(err error) *MockCall {
+	m.err = err
+	return m
+}
+
+// Return the applied flag of CAS.
+func (m *MockCall) ReturnApplied(applied bool) *MockCall {
+	m.applied = applied
+	return m
+}
+
+// Return the rows. ExecScan and ExecCAS scan the first row into dest,
+// IterRows and ExecBatchCASRows return them by SliceIterator.
+func (m *MockCall) ReturnRows(columns []string, rows ...[]interface{}) *MockCall {
+	m.rows = cb.NewSliceIterator(columns, rows...)
+	return m
+}
+
+// Expect the call n times, 0 means any times. Default is 1.
+func (m *MockCall) Times(n int) *MockCall {
+	m.times = n
+	return m
+}
+
+func (m *MockCall) satisfied() bool {
+	return m.times == 0 || m.calls >= m.times
+}
+
+func (m *MockCall) exhausted() bool {
+	return m.times > 0 && m.calls >= m.times
+}
+
+func (m *MockCall) match(r *MockRecord) bool {
+	if m.op != r.Op {
+		return false
+	}
+	if m.kind != "" && m.kind != r.Kind {
+		return false
+	}
+	if m.table != "" && m.table != r.Table {
+		return false
+	}
+	if m.statement != "" && m.statement != r.Statement {
+		return false
+	}
+	if m.hasValues {
+		if len(m.values) != len(r.Values) {
+			return false
+		}
+		for i := range m.values {
+			if !cb.ValuesEqual(m.values[i], r.Values[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (m *MockCall) String() string {
+	return fmt.Sprintf("%s %s %s cql=%q values=%v", m.op, m.kind, m.table, m.statement, m.values)
+}
+
+// Scan the first row of the expectation into dest.
+func (m *MockCall) scanFirst(dest []interface{}) error {
+	if m.rows == nil || len(m.rows.Rows) == 0 {
+		return nil
+	}
+	return cb.ScanValues(m.rows.Rows[0], dest)
+}
+
+// A fresh iterator over the rows, so the expectation can be matched many times.
+func (m *MockCall) iter() *cb.SliceIterator {
+	if m.rows == nil {
+		return cb.NewSliceIterator(nil)
+	}
+	return cb.NewSliceIterator(m.rows.Columns, m.rows.Rows...)
+}
+
+// The mock ExecManager which records every call and answers by the
+// expectations. The unexpected calls and the unmet expectations are
+// reported to testing.TB.
+// Example:
+//
+//	mock := cqlbuildertest.NewMockExecManager(t)
+//	mock.Expect(cb.OpExec).Kind(cb.KindUpdate).Table("users").Return(errors.New("boom"))
+//	mock.Expect(cb.OpIter).Statement(sel).ReturnRows([]string{"id"}, []interface{}{1})
+//	defer mock.AssertExpectations()
+type MockExecManager struct {
+	t testing.TB
+
+	mu       sync.Mutex
+	expected []*MockCall
+	records  []MockRecord
+}
+
+// Create the mock exec manager reporting to t.
+func NewMockExecManager(t testing.TB) *MockExecManager {
+	return &MockExecManager{t: t}
+}
+
+// Add the expectation of the call. The expectations are matched in the
+// order they are added.
+func (mm *MockExecManager) Expect(op cb.ExecOp) *MockCall {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	c := &MockCall{op: op, times: 1}
+	mm.expected = append(mm.expected, c)
+	return c
+}
+
+// All the recorded calls.
+func (mm *MockExecManager) Calls() []MockRecord {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return append([]MockRecord(nil), mm.records...)
+}
+
+// Report the expectations which are not met.
+func (mm *MockExecManager) AssertExpectations() bool {
+	mm.t.Helper()
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	ok := true
+	for _, c := range mm.expected {
+		if !c.satisfied() {
+			mm.t.Errorf("cqlbuildertest: expected call %s %d times, got %d", c, c.times, c.calls)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Record the call and find the expectation.
+func (mm *MockExecManager) call(op cb.ExecOp, c interface{}) (*MockCall, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	r := MockRecord{Op: op}
+	r.Table, r.Kind = cb.DescribeStatement(c)
+	r.Statement, r.Values = cb.StatementText(c)
+	mm.records = append(mm.records, r)
+
+	for _, e := range mm.expected {
+		if !e.exhausted() && e.match(&r) {
+			e.calls++
+			return e, e.err
+		}
+	}
+
+	mm.t.Helper()
+	mm.t.Errorf("cqlbuildertest: unexpected call %s %s %s cql=%q values=%v", r.Op, r.Kind, r.Table, r.Statement, r.Values)
+	return nil, ErrMockUnexpectedCall
+}
+
+// Exec the single statement
+func (mm *MockExecManager) Exec(c cb.CqlBuilder) error {
+	_, err := mm.call(cb.OpExec, c)
+	return err
+}
+
+// Exec the batch
+func (mm *MockExecManager) ExecBatch(b *cb.BatchBuilder) error {
+	_, err := mm.call(cb.OpExecBatch, b)
+	return err
+}
+
+// Exec query CAS
+func (mm *MockExecManager) ExecCAS(c cb.CqlBuilder, dest ...interface{}) (bool, error) {
+	e, err := mm.call(cb.OpExecCAS, c)
+	if e == nil || err != nil {
+		return false, err
+	}
+	return e.applied, e.scanFirst(dest)
+}
+
+// Batch CAS, the returned *cql.Iter is always nil, use ExecBatchCASRows for rows.
+func (mm *MockExecManager) ExecBatchCAS(b *cb.BatchBuilder, dest ...interface{}) (bool, *cql.Iter, error) {
+	e, err := mm.call(cb.OpExecBatchCAS, b)
+	if e == nil || err != nil {
+		return false, nil, err
+	}
+	return e.applied, nil, e.scanFirst(dest)
+}
+
+// Batch CAS, return the rows of the expectation.
+func (mm *MockExecManager) ExecBatchCASRows(b *cb.BatchBuilder, dest ...interface{}) (bool, cb.RowIterator, error) {
+	e, err := mm.call(cb.OpExecBatchCAS, b)
+	if e == nil || err != nil {
+		return false, nil, err
+	}
+	return e.applied, e.iter(), e.scanFirst(dest)
+}
+
+// run a query and fill back the first row, cql.ErrNotFound if no rows.
+func (mm *MockExecManager) ExecScan(c cb.CqlBuilder, dest ...interface{}) error {
+	e, err := mm.call(cb.OpExecScan, c)
+	if e == nil || err != nil {
+		return err
+	}
+	if e.rows == nil || len(e.rows.Rows) == 0 {
+		return cql.ErrNotFound
+	}
+	return e.scanFirst(dest)
+}
+
+// The returned *cql.Iter is always nil, use IterRows for rows.
+func (mm *MockExecManager) Iter(c cb.CqlBuilder, des ...interface{}) (*cql.Iter, error) {
+	_, err := mm.call(cb.OpIter, c)
+	return nil, err
+}
+
+// Return the rows of the expectation.
+func (mm *MockExecManager) IterRows(c cb.CqlBuilder, des ...interface{}) (cb.RowIterator, error) {
+	e, err := mm.call(cb.OpIter, c)
+	if e == nil || err != nil {
+		return nil, err
+	}
+	return e.iter(), nil
+}
+
+// Exec the single statement with context.
+func (mm *MockExecManager) ExecContext(ctx context.Context, c cb.CqlBuilder) error {
+	return mm.Exec(c)
+}
+
+// Exec the batch with context.
+func (mm *MockExecManager) ExecBatchContext(ctx context.Context, b *cb.BatchBuilder) error {
+	return mm.ExecBatch(b)
+}
+
+// Exec query CAS with context.
+func (mm *MockExecManager) ExecCASContext(ctx context.Context, c cb.CqlBuilder, dest ...interface{}) (bool, error) {
+	return mm.ExecCAS(c, dest...)
+}
+
+// Batch CAS with context.
+func (mm *MockExecManager) ExecBatchCASContext(ctx context.Context, b *cb.BatchBuilder, dest ...interface{}) (bool, *cql.Iter, error) {
+	return mm.ExecBatchCAS(b, dest...)
+}
+
+// run a query with context and fill back the result.
+func (mm *MockExecManager) ExecScanContext(ctx context.Context, c cb.CqlBuilder, dest ...interface{}) error {
+	return mm.ExecScan(c, dest...)
+}
+
+// Return result set with context.
+func (mm *MockExecManager) IterContext(ctx context.Context, c cb.CqlBuilder, des ...interface{}) (*cql.Iter, error) {
+	return mm.Iter(c, des...)
+}
+
+// Run the select and scan the first row into the struct.
+func (mm *MockExecManager) ExecScanStruct(sel *cb.SelectBuilder, dest interface{}) error {
+	return cb.SelectOne(mm, sel, dest)
+}
+
+// Run the select and append all the rows to the slice of struct.
+func (mm *MockExecManager) IterAll(sel *cb.SelectBuilder, dest interface{}) error {
+	return cb.SelectAll(mm, sel, dest)
+}
@@ -0,0 +1,69 @@
+package cqlbuildertest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	cb "cqlbuilder"
+)
+
+// Capture the errors reported to testing.TB.
+type tbRecorder struct {
+	testing.TB
+	errs []string
+}
+
+func (r *tbRecorder) Helper() {}
+
+func (r *tbRecorder) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestMockExecManager(t *testing.T) {
+	mock := NewMockExecManager(t)
+	boom := errors.New("boom")
+
+	sel := cb.Select("users").AddColumn("name").Where(cb.Eq("id", 1))
+	mock.Expect(cb.OpExec).Kind(cb.KindUpdate).Table("users").Values("a", 1).Return(boom)
+	mock.Expect(cb.OpIter).Statement(sel).ReturnRows([]string{"name"}, []interface{}{"a"}, []interface{}{"b"})
+	mock.Expect(cb.OpExecScan).Statement(sel).ReturnRows([]string{"name"}, []interface{}{"c"})
+
+	if err := mock.Exec(cb.Update("users").SetValue("name", "a").Where(cb.Eq("id", 1))); err != boom {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	iter, err := mock.IterRows(cb.Select("users").AddColumn("name").Where(cb.Eq("id", 1)))
+	if err != nil || iter.NumRows() != 2 {
+		t.Logf("err %v iter %v", err, iter)
+		t.FailNow()
+	}
+
+	var name string
+	if err := mock.ExecScan(sel, &name); err != nil || name != "c" {
+		t.Logf("err %v name %s", err, name)
+		t.FailNow()
+	}
+
+	if !mock.AssertExpectations() || len(mock.Calls()) != 3 {
+		t.Logf("calls %v", mock.Calls())
+		t.FailNow()
+	}
+}
+
+func TestMockExecManagerReport(t *testing.T) {
+	rec := &tbRecorder{TB: t}
+	mock := NewMockExecManager(rec)
+	mock.Expect(cb.OpExecCAS).Table("t").ReturnApplied(true)
+
+	if err := mock.Exec(cb.Delete("t").Where(cb.Eq("id", 1))); err != ErrMockUnexpectedCall {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	if mock.AssertExpectations() || len(rec.errs) != 2 {
+		t.Logf("errs %v", rec.errs)
+		t.FailNow()
+	}
+}
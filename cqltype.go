@@ -0,0 +1,167 @@
+package cqlbuilder
+
+import (
+	"strings"
+)
+
+// The CQL data type of the column, sth like int, list<text>,
+// frozen<map<text,int>> or a user defined type.
+type CqlType struct {
+	// The native type name, the collection(list/set/map/tuple/frozen) or
+	// the name of user defined type.
+	Name string
+	// The element types of the collection, tuple and frozen.
+	Params []CqlType
+	// The type is a user defined type.
+	UDT bool
+}
+
+// The native types.
+var (
+	Ascii     = CqlType{Name: "ascii"}
+	Bigint    = CqlType{Name: "bigint"}
+	Blob      = CqlType{Name: "blob"}
+	Boolean   = CqlType{Name: "boolean"}
+	Counter   = CqlType{Name: "counter"}
+	Date      = CqlType{Name: "date"}
+	Decimal   = CqlType{Name: "decimal"}
+	Double    = CqlType{Name: "double"}
+	Duration  = CqlType{Name: "duration"}
+	Float     = CqlType{Name: "float"}
+	Inet      = CqlType{Name: "inet"}
+	Int       = CqlType{Name: "int"}
+	Smallint  = CqlType{Name: "smallint"}
+	Text      = CqlType{Name: "text"}
+	Time      = CqlType{Name: "time"}
+	Timestamp = CqlType{Name: "timestamp"}
+	Timeuuid  = CqlType{Name: "timeuuid"}
+	Tinyint   = CqlType{Name: "tinyint"}
+	Uuid      = CqlType{Name: "uuid"}
+	Varchar   = CqlType{Name: "varchar"}
+	Varint    = CqlType{Name: "varint"}
+)
+
+// Create list<t>.
+func ListOf(t CqlType) CqlType {
+	return CqlType{Name: "list", Params: []CqlType{t}}
+}
+
+// Create set<t>.
+func SetOf(t CqlType) CqlType {
+	return CqlType{Name: "set", Params: []CqlType{t}}
+}
+
+// Create map<k,v>.
+func MapOf(k, v CqlType) CqlType {
+	return CqlType{Name: "map", Params: []CqlType{k, v}}
+}
+
+// Create tuple<t1,t2...>.
+func TupleOf(ts ...CqlType) CqlType {
+	return CqlType{Name: "tuple", Params: ts}
+}
+
+// Create frozen<t>.
+func Frozen(t CqlType) CqlType {
+	return CqlType{Name: "frozen", Params: []CqlType{t}}
+}
+
+// The user defined type, ks.type is supported.
+func UDT(name string) CqlType {
+	return CqlType{Name: name, UDT: true}
+}
+
+// Whether it's list, set or map.
+func (t CqlType) IsCollection() bool {
+	return !t.UDT && (t.Name == "list" || t.Name == "set" || t.Name == "map")
+}
+
+// Whether it's frozen<...>.
+func (t CqlType) IsFrozen() bool {
+	return !t.UDT && t.Name == "frozen"
+}
+
+// Render the type, the name of user defined type is quoted if required.
+func (t CqlType) String() string {
+	s, err := t.render()
+	if err != nil {
+		return t.Name
+	}
+	return s
+}
+
+func (t CqlType) render() (string, error) {
+	if t.UDT {
+		return QuoteTable(t.Name)
+	}
+
+	if len(t.Params) == 0 {
+		return t.Name, nil
+	}
+
+	params := make([]string, len(t.Params))
+	for i, p := range t.Params {
+		s, err := p.render()
+		if err != nil {
+			return "", err
+		}
+		params[i] = s
+	}
+	return t.Name + "<" + strings.Join(params, comma) + ">", nil
+}
+
+// Validate the type: the number of the params, and the non-frozen
+// collection or udt can't be nested in collection.
+func (t CqlType) validate() error {
+	return t.validateIn(false, false)
+}
+
+// The nested collection/udt must be frozen, everything inside the frozen
+// type or tuple is frozen implicitly.
+func (t CqlType) validateIn(nested, frozen bool) error {
+	want := -1
+	switch {
+	case t.Name == "":
+		return &TypeError{Reason: "empty type"}
+	case t.UDT:
+		if _, err := QuoteTable(t.Name); err != nil {
+			return err
+		}
+		want = 0
+	case t.Name == "list", t.Name == "set", t.Name == "frozen":
+		want = 1
+	case t.Name == "map":
+		want = 2
+	case t.Name == "tuple":
+		if len(t.Params) == 0 {
+			return &TypeError{Type: t.String(), Reason: "tuple need element types"}
+		}
+	default:
+		want = 0
+	}
+
+	if want >= 0 && len(t.Params) != want {
+		return &TypeError{Type: t.String(), Reason: "wrong number of element types"}
+	}
+
+	if nested && !frozen && (t.IsCollection() || t.UDT) {
+		return &TypeError{Type: t.String(), Reason: "nested collection/udt must be frozen"}
+	}
+
+	for _, p := range t.Params {
+		if err := p.validateIn(true, frozen || t.IsFrozen() || t.Name == "tuple"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The error of the invalid column type.
+type TypeError struct {
+	Type   string
+	Reason string
+}
+
+func (e *TypeError) Error() string {
+	return "cqlbuilder: invalid type " + e.Type + ": " + e.Reason
+}
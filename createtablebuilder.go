@@ -0,0 +1,314 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	createTable     = " CREATE TABLE "
+	primaryKey      = "PRIMARY KEY "
+	with            = " WITH "
+	clusteringOrder = "CLUSTERING ORDER BY "
+	static          = " STATIC"
+)
+
+// The column of the table.
+type columnDef struct {
+	name   string
+	typ    CqlType
+	static bool
+}
+
+type clusteringOrderDef struct {
+	column string
+	desc   bool
+}
+
+// The type of create table builder which wrap the CREATE TABLE statement.
+// Example:
+//
+//	ct := CreateTable("ks.events").IfNotExists(true).
+//		Column("tenant", Text).Column("day", Int).Column("ts", Timeuuid).
+//		Column("tags", SetOf(Text)).StaticColumn("owner", Text).
+//		PartitionKey("tenant", "day").ClusteringKey("ts").
+//		ClusteringOrder("ts", true).DefaultTTL(86400)
+//	err := em.Exec(ct)
+type CreateTableBuilder struct {
+	table          string
+	ifNotExists    bool
+	columns        []columnDef
+	partitionKeys  []string
+	clusteringKeys []string
+	orders         []clusteringOrderDef
+	options        tableOptions
+}
+
+// Create the create table builder.
+func CreateTable(t string) *CreateTableBuilder {
+	return &CreateTableBuilder{table: t}
+}
+
+// Add a column.
+func (c *CreateTableBuilder) Column(name string, typ CqlType) *CreateTableBuilder {
+	c.columns = append(c.columns, columnDef{name: name, typ: typ})
+	return c
+}
+
+// Add a static column, it's shared by all the rows of a partition.
+func (c *CreateTableBuilder) StaticColumn(name string, typ CqlType) *CreateTableBuilder {
+	c.columns = append(c.columns, columnDef{name: name, typ: typ, static: true})
+	return c
+}
+
+// Add the partition key columns, more than one makes a composite partition key.
+func (c *CreateTableBuilder) PartitionKey(columns ...string) *CreateTableBuilder {
+	c.partitionKeys = append(c.partitionKeys, columns...)
+	return c
+}
+
+// Add the clustering key columns.
+func (c *CreateTableBuilder) ClusteringKey(columns ...string) *CreateTableBuilder {
+	c.clusteringKeys = append(c.clusteringKeys, columns...)
+	return c
+}
+
+// Add the CLUSTERING ORDER BY of the clustering column.
+func (c *CreateTableBuilder) ClusteringOrder(column string, desc bool) *CreateTableBuilder {
+	c.orders = append(c.orders, clusteringOrderDef{column: column, desc: desc})
+	return c
+}
+
+// Set if not exists
+func (c *CreateTableBuilder) IfNotExists(e bool) *CreateTableBuilder {
+	c.ifNotExists = e
+	return c
+}
+
+// Set the table option, the value is a string, number, bool or map[string]...
+// Example:
+//
+//	With("comment", "the events").With("speculative_retry", "99p")
+func (c *CreateTableBuilder) With(name string, value interface{}) *CreateTableBuilder {
+	c.options.set(name, value)
+	return c
+}
+
+// Set the compaction strategy class and its sub options.
+func (c *CreateTableBuilder) Compaction(class string, opts map[string]interface{}) *CreateTableBuilder {
+	return c.With("compaction", compactionOption(class, opts))
+}
+
+// Set default_time_to_live in seconds.
+func (c *CreateTableBuilder) DefaultTTL(seconds int) *CreateTableBuilder {
+	return c.With("default_time_to_live", seconds)
+}
+
+// Set gc_grace_seconds.
+func (c *CreateTableBuilder) GcGraceSeconds(seconds int) *CreateTableBuilder {
+	return c.With("gc_grace_seconds", seconds)
+}
+
+// Set caching, keys is ALL or NONE, rowsPerPartition is ALL, NONE or a number.
+func (c *CreateTableBuilder) Caching(keys, rowsPerPartition string) *CreateTableBuilder {
+	return c.With("caching", map[string]string{"keys": keys, "rows_per_partition": rowsPerPartition})
+}
+
+func compactionOption(class string, opts map[string]interface{}) map[string]interface{} {
+	m := map[string]interface{}{"class": class}
+	for k, v := range opts {
+		m[k] = v
+	}
+	return m
+}
+
+func (c *CreateTableBuilder) column(name string) *columnDef {
+	for i := range c.columns {
+		if c.columns[i].name == name {
+			return &c.columns[i]
+		}
+	}
+	return nil
+}
+
+func (c *CreateTableBuilder) schemaError(reason string) error {
+	return &SchemaError{Statement: "CREATE TABLE " + c.table, Reason: reason}
+}
+
+// Validate
+func (c *CreateTableBuilder) Validate() error {
+	if len(c.table) == 0 {
+		return errEmptyTable
+	}
+
+	if len(c.columns) == 0 {
+		return errEmptyColumn
+	}
+
+	if len(c.partitionKeys) == 0 {
+		return c.schemaError("need partition key")
+	}
+
+	seen := map[string]bool{}
+	for _, col := range c.columns {
+		if seen[col.name] {
+			return c.schemaError("duplicated column " + col.name)
+		}
+		seen[col.name] = true
+
+		if err := col.typ.validate(); err != nil {
+			return err
+		}
+	}
+
+	isKey := map[string]bool{}
+	for _, k := range append(append([]string(nil), c.partitionKeys...), c.clusteringKeys...) {
+		col := c.column(k)
+		switch {
+		case col == nil:
+			return c.schemaError("key column " + k + " is not defined")
+		case isKey[k]:
+			return c.schemaError("duplicated key column " + k)
+		case col.static:
+			return c.schemaError("key column " + k + " can't be static")
+		case col.typ.IsCollection() || col.typ.UDT || col.typ.Name == Counter.Name || col.typ.Name == Duration.Name:
+			return c.schemaError("key column " + k + " can't be " + col.typ.String())
+		}
+		isKey[k] = true
+	}
+
+	counters, others := 0, 0
+	for _, col := range c.columns {
+		if isKey[col.name] {
+			continue
+		}
+		if col.static && len(c.clusteringKeys) == 0 {
+			return c.schemaError("static column " + col.name + " need clustering key")
+		}
+		if col.typ.Name == Counter.Name {
+			counters++
+		} else {
+			others++
+		}
+	}
+	if counters > 0 && others > 0 {
+		return c.schemaError("counter columns can't be mixed with other columns")
+	}
+	if _, ok := c.options.values["default_time_to_live"]; ok && counters > 0 {
+		return c.schemaError("counter table can't have default_time_to_live")
+	}
+
+	for i, o := range c.orders {
+		if i >= len(c.clusteringKeys) || c.clusteringKeys[i] != o.column {
+			return c.schemaError("clustering order must follow the clustering keys, got " + o.column)
+		}
+	}
+
+	return nil
+}
+
+// Build the CREATE TABLE statement, it has no values.
+// Example:
+//
+//	CREATE TABLE IF NOT EXISTS ks.events (tenant text,day int,ts timeuuid,PRIMARY KEY ((tenant,day),ts)) WITH CLUSTERING ORDER BY (ts DESC)
+func (c *CreateTableBuilder) ToQuery() (string, []interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(createTable)
+	if c.ifNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	buf.WriteString(table)
+	buf.WriteString(space + leftPar)
+
+	for _, col := range c.columns {
+		name, err := QuoteIdentifier(col.name)
+		if err != nil {
+			return "", nil, err
+		}
+		typ, err := col.typ.render()
+		if err != nil {
+			return "", nil, err
+		}
+
+		buf.WriteString(name + space + typ)
+		if col.static {
+			buf.WriteString(static)
+		}
+		buf.WriteString(comma)
+	}
+
+	pks, err := quoteIdentifiers(c.partitionKeys)
+	if err != nil {
+		return "", nil, err
+	}
+	cks, err := quoteIdentifiers(c.clusteringKeys)
+	if err != nil {
+		return "", nil, err
+	}
+
+	buf.WriteString(primaryKey + leftPar)
+	if len(pks) > 1 {
+		buf.WriteString(leftPar + strings.Join(pks, comma) + rightPar)
+	} else {
+		buf.WriteString(pks[0])
+	}
+	for _, ck := range cks {
+		buf.WriteString(comma + ck)
+	}
+	buf.WriteString(rightPar + rightPar)
+
+	withs := make([]string, 0, 2)
+	if len(c.orders) > 0 {
+		var order bytes.Buffer
+		order.WriteString(clusteringOrder + leftPar)
+		for i, o := range c.orders {
+			if i > 0 {
+				order.WriteString(comma)
+			}
+			order.WriteString(cks[i])
+			if o.desc {
+				order.WriteString(desc)
+			} else {
+				order.WriteString(asc)
+			}
+		}
+		order.WriteString(rightPar)
+		withs = append(withs, order.String())
+	}
+
+	if !c.options.empty() {
+		opts, err := c.options.render()
+		if err != nil {
+			return "", nil, err
+		}
+		withs = append(withs, opts)
+	}
+
+	for i, w := range withs {
+		if i == 0 {
+			buf.WriteString(with)
+		} else {
+			buf.WriteString(and)
+		}
+		buf.WriteString(w)
+	}
+
+	return buf.String(), nil, nil
+}
+
+func (c *CreateTableBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *CreateTableBuilder) statementKind() StatementKind {
+	return KindSchema
+}
@@ -0,0 +1,132 @@
+package cqlbuilder
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCreateTable(t *testing.T) {
+	ct := CreateTable("ks.events").IfNotExists(true).
+		Column("tenant", Text).Column("day", Int).Column("ts", Timeuuid).
+		Column("tags", SetOf(Text)).
+		Column("attrs", MapOf(Text, Frozen(ListOf(Int)))).
+		Column("addr", Frozen(UDT("address"))).
+		Column("pos", TupleOf(Double, Double)).
+		StaticColumn("owner", Text).
+		Column("userId", Uuid).
+		PartitionKey("tenant", "day").ClusteringKey("ts").
+		ClusteringOrder("ts", true).
+		Compaction("TimeWindowCompactionStrategy", map[string]interface{}{"compaction_window_unit": "DAYS", "compaction_window_size": 1}).
+		DefaultTTL(86400).GcGraceSeconds(3600).Caching("ALL", "NONE").
+		With("comment", "it's the events")
+
+	str, vals, err := ct.ToQuery()
+	expected := ` CREATE TABLE IF NOT EXISTS ks.events (tenant text,day int,ts timeuuid,tags set<text>,attrs map<text,frozen<list<int>>>,addr frozen<address>,pos tuple<double,double>,owner text STATIC,userId uuid,PRIMARY KEY ((tenant,day),ts))` +
+		` WITH CLUSTERING ORDER BY (ts DESC) AND compaction = {'class': 'TimeWindowCompactionStrategy', 'compaction_window_size': 1, 'compaction_window_unit': 'DAYS'}` +
+		` AND default_time_to_live = 86400 AND gc_grace_seconds = 3600 AND caching = {'keys': 'ALL', 'rows_per_partition': 'NONE'} AND comment = 'it''s the events'`
+	if err != nil || str != expected || len(vals) != 0 {
+		t.Logf("str %s err %v", str, err)
+		t.FailNow()
+	}
+
+	str, _, err = CreateTable("counts").Column("id", Text).Column("n", Counter).PartitionKey("id").ToQuery()
+	if err != nil || str != " CREATE TABLE counts (id text,n counter,PRIMARY KEY (id))" {
+		t.Logf("str %s err %v", str, err)
+		t.FailNow()
+	}
+
+	tbl, kind := describeStatement(ct)
+	if tbl != "ks.events" || kind != KindSchema {
+		t.Logf("table %s kind %s", tbl, kind)
+		t.FailNow()
+	}
+}
+
+func TestCreateTableValidate(t *testing.T) {
+	cases := []*CreateTableBuilder{
+		CreateTable("t").Column("id", Int),
+		CreateTable("t").Column("id", Int).Column("id", Text).PartitionKey("id"),
+		CreateTable("t").Column("id", Int).PartitionKey("other"),
+		CreateTable("t").Column("id", ListOf(Int)).PartitionKey("id"),
+		CreateTable("t").Column("id", Int).StaticColumn("s", Int).PartitionKey("id"),
+		CreateTable("t").Column("id", Int).Column("n", Counter).Column("v", Text).PartitionKey("id"),
+		CreateTable("t").Column("id", Int).Column("n", Counter).PartitionKey("id").DefaultTTL(10),
+		CreateTable("t").Column("id", Int).Column("a", Int).Column("b", Int).PartitionKey("id").ClusteringKey("a", "b").ClusteringOrder("b", true),
+		CreateTable("t").Column("id", Int).Column("m", MapOf(Text, ListOf(Int))).PartitionKey("id"),
+		CreateTable("t").Column("id", Int).Column("l", ListOf(UDT("address"))).PartitionKey("id"),
+	}
+
+	for i, c := range cases {
+		if _, _, err := c.ToQuery(); err == nil {
+			t.Logf("case %d: err expected", i)
+			t.FailNow()
+		}
+	}
+
+	// Everything inside the frozen type or tuple is frozen implicitly.
+	valid := []CqlType{
+		Frozen(MapOf(Text, ListOf(Int))),
+		Frozen(ListOf(MapOf(Text, SetOf(Int)))),
+		TupleOf(Int, ListOf(Int)),
+		ListOf(Frozen(MapOf(Text, ListOf(UDT("address"))))),
+	}
+	for i, typ := range valid {
+		if _, _, err := CreateTable("t").Column("id", Int).Column("c", typ).PartitionKey("id").ToQuery(); err != nil {
+			t.Logf("case %d: err %v", i, err)
+			t.FailNow()
+		}
+	}
+
+	// The frozen doesn't reach the collection nested in the non frozen one.
+	typ := ListOf(MapOf(Text, Frozen(ListOf(Int))))
+	if _, _, err := CreateTable("t").Column("id", Int).Column("c", typ).PartitionKey("id").ToQuery(); err == nil {
+		t.Logf("err expected for %s", typ)
+		t.FailNow()
+	}
+}
+
+func TestFakeCreateTable(t *testing.T) {
+	f := NewFakeExecManager()
+	ct := CreateTable("users").IfNotExists(true).Column("id", Text).Column("name", Text).PartitionKey("id")
+	if err := f.Exec(ct); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	if err := f.Exec(Insert("users").SetValue("id", "u1").SetValue("name", "Alice")); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	if err := f.Exec(ct); err != nil || f.Get("users", "u1") == nil {
+		t.Logf("err %v, the table is kept by IF NOT EXISTS", err)
+		t.FailNow()
+	}
+
+	if err := f.Exec(ct.IfNotExists(false)); err != ErrFakeTableExists || f.Get("users", "u1") == nil {
+		t.Logf("err %v, the table is kept", err)
+		t.FailNow()
+	}
+
+	// Only one of the concurrent CREATE TABLE succeeds.
+	orgs := CreateTable("orgs").Column("id", Text).PartitionKey("id")
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if f.Exec(orgs) == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if created != 1 {
+		t.Logf("created %d times", created)
+		t.FailNow()
+	}
+}
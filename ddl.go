@@ -0,0 +1,105 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The error of the invalid DDL statement.
+type SchemaError struct {
+	Statement string
+	Reason    string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("cqlbuilder: invalid %s: %s", e.Statement, e.Reason)
+}
+
+// The WITH options of the table, kept in the order they are set. Setting
+// the same option again overwrites the old value.
+type tableOptions struct {
+	names  []string
+	values map[string]interface{}
+}
+
+func (o *tableOptions) set(name string, value interface{}) {
+	if o.values == nil {
+		o.values = map[string]interface{}{}
+	}
+	if _, ok := o.values[name]; !ok {
+		o.names = append(o.names, name)
+	}
+	o.values[name] = value
+}
+
+func (o *tableOptions) empty() bool {
+	return len(o.names) == 0
+}
+
+// Render the options as "name = literal AND name = literal".
+func (o *tableOptions) render() (string, error) {
+	parts := make([]string, 0, len(o.names))
+	for _, name := range o.names {
+		if !plainIdentifier.MatchString(name) {
+			return "", &IdentifierError{Name: name, Reason: "invalid option name"}
+		}
+
+		lit, err := optionLiteral(o.values[name])
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, name+" = "+lit)
+	}
+	return strings.Join(parts, and), nil
+}
+
+// Render the value of the DDL option: the string is single quoted, the
+// map is rendered as {'k': v, ...} with the keys sorted, class first.
+func optionLiteral(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return "'" + strings.Replace(val, "'", "''", -1) + "'", nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", val), nil
+	case float32, float64:
+		return fmt.Sprintf("%v", val), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return "", fmt.Errorf("cqlbuilder: unsupported option value %v(%T)", v, v)
+	}
+
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	// The class goes first, sth like {'class': 'SimpleStrategy', ...}.
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == "class" || keys[j] == "class" {
+			return keys[i] == "class"
+		}
+		return keys[i] < keys[j]
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		lit, err := optionLiteral(rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface())
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString("'" + strings.Replace(k, "'", "''", -1) + "': " + lit)
+	}
+	buf.WriteString("}")
+	return buf.String(), nil
+}
@@ -11,6 +11,8 @@ type DeleteBuilder struct {
 	table           string
 	ifConditions    []conditionBuilder
 	whereConditions []conditionBuilder
+	options         *QueryOptions
+	schema          *TableSchema
 }
 
 // The add delete column.
@@ -53,12 +55,28 @@ func (c *DeleteBuilder) ToQuery() (string, []interface{}, error) {
 		return "", nil, err
 	}
 
+	if c.schema != nil {
+		if err := c.schema.validateDelete(c); err != nil {
+			return "", nil, err
+		}
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns, err := quoteTargets(c.colums)
+	if err != nil {
+		return "", nil, err
+	}
+
 	var buf bytes.Buffer
 	values := make([]interface{}, 0, len(c.ifConditions)+len(c.whereConditions))
 
 	buf.WriteString(deleteKW)
 
-	for i, col := range c.colums {
+	for i, col := range columns {
 		if i > 0 {
 			buf.WriteString(comma)
 		}
@@ -66,12 +84,15 @@ func (c *DeleteBuilder) ToQuery() (string, []interface{}, error) {
 	}
 
 	buf.WriteString(from)
-	buf.WriteString(c.table)
+	buf.WriteString(table)
 
 	if len(c.whereConditions) > 0 {
 		buf.WriteString(where)
 
-		condition, conditionValues := buildCondition(c.whereConditions)
+		condition, conditionValues, err := buildCondition(c.whereConditions)
+		if err != nil {
+			return "", nil, err
+		}
 		buf.WriteString(condition)
 		values = append(values, conditionValues...)
 	}
@@ -79,10 +100,38 @@ func (c *DeleteBuilder) ToQuery() (string, []interface{}, error) {
 	if len(c.ifConditions) > 0 {
 		buf.WriteString(ifs)
 
-		condition, conditionValues := buildCondition(c.ifConditions)
+		condition, conditionValues, err := buildCondition(c.ifConditions)
+		if err != nil {
+			return "", nil, err
+		}
 		buf.WriteString(condition)
 		values = append(values, conditionValues...)
 	}
 
 	return buf.String(), values, nil
 }
+
+// Attach the query options(consistency, page size ...) to the statement.
+func (c *DeleteBuilder) SetOptions(o *QueryOptions) *DeleteBuilder {
+	c.options = o
+	return c
+}
+
+// Bind the statement to the table schema, ToQuery validates the columns,
+// keys and value types by it.
+func (c *DeleteBuilder) SetSchema(s *TableSchema) *DeleteBuilder {
+	c.schema = s
+	return c
+}
+
+func (c *DeleteBuilder) queryOptions() *QueryOptions {
+	return c.options
+}
+
+func (c *DeleteBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *DeleteBuilder) statementKind() StatementKind {
+	return KindDelete
+}
@@ -2,5 +2,5 @@
 // Main function :
 //  1. Wrap CQL insert/update/delete
 //  2. Wrap CQL Batch
-//  2. Log (TODO)
+//  3. Log/trace the executed statements by ExecObserver
 package cqlbuilder
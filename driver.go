@@ -0,0 +1,449 @@
+package cqlbuilder
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	cql "github.com/gocql/gocql"
+)
+
+var (
+	errNotPreparable = errors.New("cqlbuilder: only SELECT, INSERT, UPDATE and DELETE can be prepared")
+	errPrepareOnly   = errors.New("cqlbuilder: prepare only")
+)
+
+var (
+	ErrDriverMismatch         = errors.New("cqlbuilder: batch is not created by the session")
+	ErrDriverIterNotSupported = errors.New("cqlbuilder: the driver can't return *cql.Iter, use IterRows")
+)
+
+// The session cqlbuilder executes against. The gocql session is adapted by
+// NewGocqlSession, other drivers(scylla fork, proxy client, local fake) can
+// be plugged in by implementing it and set to SessionExecManager.Driver.
+type Session interface {
+	// Create the query of the statement.
+	Query(stmt string, values ...interface{}) SessionQuery
+	// Create the batch.
+	NewBatch(typ cql.BatchType) SessionBatch
+	// Exec the batch created by NewBatch.
+	ExecuteBatch(b SessionBatch) error
+	// Exec the CAS batch created by NewBatch.
+	ExecuteBatchCAS(b SessionBatch, dest ...interface{}) (bool, RowIterator, error)
+}
+
+// The query of the Session.
+type SessionQuery interface {
+	WithContext(ctx context.Context) SessionQuery
+	Consistency(c cql.Consistency) SessionQuery
+	SerialConsistency(c cql.SerialConsistency) SessionQuery
+	PageSize(n int) SessionQuery
+	PageState(state []byte) SessionQuery
+	Idempotent(i bool) SessionQuery
+	RetryPolicy(r cql.RetryPolicy) SessionQuery
+	DefaultTimestamp(enable bool) SessionQuery
+	WithTimestamp(ts int64) SessionQuery
+	Trace(t cql.Tracer) SessionQuery
+
+	// Exec the statement.
+	Exec() error
+	// Exec the query and scan the first row.
+	Scan(dest ...interface{}) error
+	// Exec the CAS statement.
+	ScanCAS(dest ...interface{}) (bool, error)
+	// Exec the query and return the result set.
+	Iter() RowIterator
+}
+
+// The batch of the Session.
+type SessionBatch interface {
+	WithContext(ctx context.Context) SessionBatch
+	// Add the statement to the batch.
+	Query(stmt string, values ...interface{})
+	Consistency(c cql.Consistency) SessionBatch
+	SerialConsistency(c cql.SerialConsistency) SessionBatch
+	// Mark all the statements of the batch idempotent.
+	Idempotent(i bool) SessionBatch
+	RetryPolicy(r cql.RetryPolicy) SessionBatch
+	DefaultTimestamp(enable bool) SessionBatch
+	WithTimestamp(ts int64) SessionBatch
+	Trace(t cql.Tracer) SessionBatch
+}
+
+var (
+	_ Session           = (*gocqlSession)(nil)
+	_ StatementPreparer = (*gocqlSession)(nil)
+)
+
+// Adapt the gocql session to Session.
+func NewGocqlSession(s *cql.Session) Session {
+	return &gocqlSession{s: s}
+}
+
+type gocqlSession struct {
+	s *cql.Session
+}
+
+func (g *gocqlSession) Query(stmt string, values ...interface{}) SessionQuery {
+	q := g.s.Query(stmt, values...)
+	if q == nil {
+		return nil
+	}
+	return &gocqlQuery{q: q}
+}
+
+func (g *gocqlSession) NewBatch(typ cql.BatchType) SessionBatch {
+	return &gocqlBatch{b: g.s.NewBatch(typ)}
+}
+
+func (g *gocqlSession) ExecuteBatch(b SessionBatch) error {
+	gb, ok := b.(*gocqlBatch)
+	if !ok {
+		return ErrDriverMismatch
+	}
+	return g.s.ExecuteBatch(gb.b)
+}
+
+func (g *gocqlSession) ExecuteBatchCAS(b SessionBatch, dest ...interface{}) (bool, RowIterator, error) {
+	gb, ok := b.(*gocqlBatch)
+	if !ok {
+		return false, nil, ErrDriverMismatch
+	}
+
+	applied, iter, err := g.s.ExecuteBatchCAS(gb.b, dest...)
+	if iter == nil {
+		return applied, nil, err
+	}
+	return applied, iter, err
+}
+
+// Prepare the statement on the host picked by gocql. gocql has no API to
+// prepare only, so the statement is bound by the callback which fails after
+// the prepare, the statement is never executed.
+func (g *gocqlSession) Prepare(ctx context.Context, stmt string) error {
+	// gocql executes the other statements without prepare.
+	if !preparable(stmt) {
+		return errNotPreparable
+	}
+
+	err := g.s.Bind(stmt, func(*cql.QueryInfo) ([]interface{}, error) {
+		return nil, errPrepareOnly
+	}).WithContext(ctx).RetryPolicy(nil).Exec()
+	if errors.Is(err, errPrepareOnly) {
+		return nil
+	}
+	return err
+}
+
+// Whether gocql prepares the statement, the same check as gocql's.
+func preparable(stmt string) bool {
+	fields := strings.Fields(stmt)
+	if len(fields) < 2 {
+		return false
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "select", "insert", "update", "delete":
+		return true
+	}
+	return false
+}
+
+type gocqlQuery struct {
+	q *cql.Query
+}
+
+func (g *gocqlQuery) WithContext(ctx context.Context) SessionQuery {
+	g.q = g.q.WithContext(ctx)
+	return g
+}
+
+func (g *gocqlQuery) Consistency(c cql.Consistency) SessionQuery {
+	g.q.Consistency(c)
+	return g
+}
+
+func (g *gocqlQuery) SerialConsistency(c cql.SerialConsistency) SessionQuery {
+	g.q.SerialConsistency(c)
+	return g
+}
+
+func (g *gocqlQuery) PageSize(n int) SessionQuery {
+	g.q.PageSize(n)
+	return g
+}
+
+func (g *gocqlQuery) PageState(state []byte) SessionQuery {
+	g.q.PageState(state)
+	return g
+}
+
+func (g *gocqlQuery) Idempotent(i bool) SessionQuery {
+	g.q.Idempotent(i)
+	return g
+}
+
+func (g *gocqlQuery) RetryPolicy(r cql.RetryPolicy) SessionQuery {
+	g.q.RetryPolicy(r)
+	return g
+}
+
+func (g *gocqlQuery) DefaultTimestamp(enable bool) SessionQuery {
+	g.q.DefaultTimestamp(enable)
+	return g
+}
+
+func (g *gocqlQuery) WithTimestamp(ts int64) SessionQuery {
+	g.q.WithTimestamp(ts)
+	return g
+}
+
+func (g *gocqlQuery) Trace(t cql.Tracer) SessionQuery {
+	g.q.Trace(t)
+	return g
+}
+
+func (g *gocqlQuery) Exec() error {
+	return g.q.Exec()
+}
+
+func (g *gocqlQuery) Scan(dest ...interface{}) error {
+	return g.q.Scan(dest...)
+}
+
+func (g *gocqlQuery) ScanCAS(dest ...interface{}) (bool, error) {
+	return g.q.ScanCAS(dest...)
+}
+
+func (g *gocqlQuery) Iter() RowIterator {
+	return g.q.Iter()
+}
+
+type gocqlBatch struct {
+	b *cql.Batch
+}
+
+func (g *gocqlBatch) WithContext(ctx context.Context) SessionBatch {
+	g.b = g.b.WithContext(ctx)
+	return g
+}
+
+func (g *gocqlBatch) Query(stmt string, values ...interface{}) {
+	g.b.Query(stmt, values...)
+}
+
+func (g *gocqlBatch) Consistency(c cql.Consistency) SessionBatch {
+	g.b.SetConsistency(c)
+	return g
+}
+
+func (g *gocqlBatch) SerialConsistency(c cql.SerialConsistency) SessionBatch {
+	g.b.SerialConsistency(c)
+	return g
+}
+
+func (g *gocqlBatch) Idempotent(i bool) SessionBatch {
+	for n := range g.b.Entries {
+		g.b.Entries[n].Idempotent = i
+	}
+	return g
+}
+
+func (g *gocqlBatch) RetryPolicy(r cql.RetryPolicy) SessionBatch {
+	g.b.RetryPolicy(r)
+	return g
+}
+
+func (g *gocqlBatch) DefaultTimestamp(enable bool) SessionBatch {
+	g.b.DefaultTimestamp(enable)
+	return g
+}
+
+func (g *gocqlBatch) WithTimestamp(ts int64) SessionBatch {
+	g.b.WithTimestamp(ts)
+	return g
+}
+
+func (g *gocqlBatch) Trace(t cql.Tracer) SessionBatch {
+	g.b.Trace(t)
+	return g
+}
+
+// Get the *cql.Iter back from the RowIterator created by the gocql adapter.
+func toGocqlIter(iter RowIterator) (*cql.Iter, error) {
+	if iter == nil {
+		return nil, nil
+	}
+	it, ok := iter.(*cql.Iter)
+	if !ok {
+		return nil, ErrDriverIterNotSupported
+	}
+	return it, nil
+}
+
+// Exec the single statement on the driver, Exec takes the *cql.Session.
+func ExecDriver(c CqlBuilder, s Session) error {
+	return ExecDriverContext(context.Background(), c, s)
+}
+
+// Exec the single statement on the driver with context.
+func ExecDriverContext(ctx context.Context, c CqlBuilder, s Session) error {
+	return execStatement(ctx, c, s)
+}
+
+// Exec the batch on the driver.
+func ExecBatchDriver(b *BatchBuilder, s Session) error {
+	return ExecBatchDriverContext(context.Background(), b, s)
+}
+
+// Exec the batch on the driver with context.
+func ExecBatchDriverContext(ctx context.Context, b *BatchBuilder, s Session) error {
+	return execBatch(ctx, b, s)
+}
+
+// Exec query CAS on the driver.
+func ExecCASDriver(c CqlBuilder, s Session, dest ...interface{}) (bool, error) {
+	return ExecCASDriverContext(context.Background(), c, s, dest...)
+}
+
+// Exec query CAS on the driver with context.
+func ExecCASDriverContext(ctx context.Context, c CqlBuilder, s Session, dest ...interface{}) (bool, error) {
+	return execCAS(ctx, c, s, dest...)
+}
+
+// Batch CAS on the driver, return the RowIterator.
+func ExecBatchCASRowsDriver(b *BatchBuilder, s Session, dest ...interface{}) (bool, RowIterator, error) {
+	return ExecBatchCASRowsDriverContext(context.Background(), b, s, dest...)
+}
+
+// Batch CAS on the driver with context, return the RowIterator.
+func ExecBatchCASRowsDriverContext(ctx context.Context, b *BatchBuilder, s Session, dest ...interface{}) (bool, RowIterator, error) {
+	return execBatchCAS(ctx, b, s, dest...)
+}
+
+// Run a query on the driver and fill back the result.
+func ExecScanDriver(c CqlBuilder, s Session, dest ...interface{}) error {
+	return ExecScanDriverContext(context.Background(), c, s, dest...)
+}
+
+// Run a query on the driver with context and fill back the result.
+func ExecScanDriverContext(ctx context.Context, c CqlBuilder, s Session, dest ...interface{}) error {
+	return execScan(ctx, c, s, dest...)
+}
+
+// Return result set of the driver as RowIterator.
+func IterRowsDriver(c CqlBuilder, s Session) (RowIterator, error) {
+	return IterRowsDriverContext(context.Background(), c, s)
+}
+
+// Return result set of the driver as RowIterator, the query is bound to the
+// context.
+func IterRowsDriverContext(ctx context.Context, c CqlBuilder, s Session) (RowIterator, error) {
+	return iterRows(ctx, c, s)
+}
+
+// Exec the single statement on the session.
+func execStatement(ctx context.Context, c CqlBuilder, s Session) error {
+	q, err := newQuery(ctx, c, s)
+	if err != nil {
+		return err
+	}
+
+	return q.Exec()
+}
+
+// Exec the batch on the session.
+func execBatch(ctx context.Context, b *BatchBuilder, s Session) error {
+	batch, err := buildBatch(ctx, b, s)
+	if err != nil {
+		return err
+	}
+
+	return s.ExecuteBatch(batch)
+}
+
+// Exec the CAS statement on the session.
+func execCAS(ctx context.Context, c CqlBuilder, s Session, dest ...interface{}) (bool, error) {
+	q, err := newQuery(ctx, c, s)
+	if err != nil {
+		return false, err
+	}
+
+	return q.ScanCAS(dest...)
+}
+
+// Exec the CAS batch on the session.
+func execBatchCAS(ctx context.Context, b *BatchBuilder, s Session, dest ...interface{}) (bool, RowIterator, error) {
+	batch, err := buildBatch(ctx, b, s)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return s.ExecuteBatchCAS(batch, dest...)
+}
+
+// Run the query on the session and fill back the result.
+func execScan(ctx context.Context, c CqlBuilder, s Session, dest ...interface{}) error {
+	q, err := newQuery(ctx, c, s)
+	if err != nil {
+		return err
+	}
+
+	return q.Scan(dest...)
+}
+
+// Run the query on the session and return the result set, the nil iterator
+// of the driver is the error.
+func iterRows(ctx context.Context, c CqlBuilder, s Session) (RowIterator, error) {
+	q, err := newQuery(ctx, c, s)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := q.Iter()
+	if iter == nil {
+		return nil, ErrPreparingQueryFailed
+	}
+	return iter, nil
+}
+
+// Build the query from the builder, the query options of the builder are
+// applied.
+func newQuery(ctx context.Context, c CqlBuilder, s Session) (SessionQuery, error) {
+	cqlstr, vals, err := c.ToQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.Query(cqlstr, vals...)
+	if q == nil {
+		return nil, ErrPreparingQueryFailed
+	}
+
+	return optionsOf(c).applyQuery(q.WithContext(ctx)), nil
+}
+
+// Build the batch from the batch builder, the query options of the batch
+// are applied. The batch is idempotent if all its statements are.
+func buildBatch(ctx context.Context, b *BatchBuilder, s Session) (SessionBatch, error) {
+	batch := s.NewBatch(cql.LoggedBatch).WithContext(ctx)
+	idempotent := len(b.builders) > 0
+	for _, q := range b.builders {
+		str, vals, err := q.ToQuery()
+		if err != nil {
+			return nil, err
+		}
+
+		batch.Query(str, vals...)
+
+		if o := optionsOf(q); o == nil || !o.idempotent {
+			idempotent = false
+		}
+	}
+
+	if idempotent {
+		batch.Idempotent(true)
+	}
+
+	return optionsOf(b).applyBatch(batch), nil
+}
@@ -1,7 +1,10 @@
 package cqlbuilder
 
 import (
+	"context"
 	"errors"
+	"time"
+
 	cql "github.com/gocql/gocql"
 )
 
@@ -31,46 +34,223 @@ type ExecManager interface {
 	Iter(c CqlBuilder, des ...interface{}) (*cql.Iter, error)
 }
 
+// The new version of execution interface, which return RowIterator instead
+// of *cql.Iter so the result set can be mocked by SliceIterator in UT.
+type ExecManagerV2 interface {
+	// Exec the single statement
+	Exec(c CqlBuilder) error
+
+	// Exec the batch
+	ExecBatch(b *BatchBuilder) error
+
+	//Exec query CAS
+	ExecCAS(c CqlBuilder, dest ...interface{}) (bool, error)
+
+	//Batch CAS
+	ExecBatchCASRows(b *BatchBuilder, dest ...interface{}) (applied bool, iter RowIterator, err error)
+
+	// run a query and fill back the result.
+	ExecScan(c CqlBuilder, dest ...interface{}) error
+
+	// Return result set.
+	IterRows(c CqlBuilder, des ...interface{}) (RowIterator, error)
+}
+
+// The execution interface which pass the context through to gocql, so the
+// cancellation and deadline of the request can reach the driver.
+type ExecManagerContext interface {
+	ExecManager
+
+	// Exec the single statement
+	ExecContext(ctx context.Context, c CqlBuilder) error
+
+	// Exec the batch
+	ExecBatchContext(ctx context.Context, b *BatchBuilder) error
+
+	//Exec query CAS
+	ExecCASContext(ctx context.Context, c CqlBuilder, dest ...interface{}) (bool, error)
+
+	//Batch CAS
+	ExecBatchCASContext(ctx context.Context, b *BatchBuilder, dest ...interface{}) (applied bool, iter *cql.Iter, err error)
+
+	// run a query and fill back the result.
+	ExecScanContext(ctx context.Context, c CqlBuilder, dest ...interface{}) error
+
+	// Return result set.
+	IterContext(ctx context.Context, c CqlBuilder, des ...interface{}) (*cql.Iter, error)
+}
+
+var (
+	_ ExecManagerContext = (*SessionExecManager)(nil)
+	_ ExecManagerV2      = (*SessionExecManager)(nil)
+)
+
 type SessionExecManager struct {
 	Session *cql.Session
+	// The driver to exec against, the Session is used if nil.
+	Driver Session
+
+	// The optional observer which receives every executed statement.
+	Observer ExecObserver
+	// The optional redactor applied to the values before passing them to the observer.
+	Redact ValueRedactor
+	// The optional registry which tracks the CQL shapes.
+	Registry *StatementRegistry
 }
 
 // Exec the single statement
 func (em *SessionExecManager) Exec(c CqlBuilder) error {
-	return Exec(c, em.Session)
+	return em.ExecContext(context.Background(), c)
 }
 
 // Exec the batch
 func (em *SessionExecManager) ExecBatch(b *BatchBuilder) error {
-	return ExecBatch(b, em.Session)
+	return em.ExecBatchContext(context.Background(), b)
 }
 
 //Exec query CAS
 func (em *SessionExecManager) ExecCAS(c CqlBuilder, dest ...interface{}) (bool, error) {
-	return ExecCAS(c, em.Session, dest...)
+	return em.ExecCASContext(context.Background(), c, dest...)
 }
 
 //Batch CAS
 func (em *SessionExecManager) ExecBatchCAS(b *BatchBuilder, dest ...interface{}) (applied bool, iter *cql.Iter, err error) {
-	return ExecBatchCAS(b, em.Session, dest...)
+	return em.ExecBatchCASContext(context.Background(), b, dest...)
 }
 
 // run a query and fill back the result.
 func (em *SessionExecManager) ExecScan(c CqlBuilder, dest ...interface{}) error {
-	return ExecScan(c, em.Session, dest...)
+	return em.ExecScanContext(context.Background(), c, dest...)
 }
 
 // Return result set
-func (em *SessionExecManager) Iter(c CqlBuilder, des ...interface{}) (*cql.Iter, error){
-	cqlstr, vals, err := c.ToQuery()
+func (em *SessionExecManager) Iter(c CqlBuilder, des ...interface{}) (*cql.Iter, error) {
+	return em.IterContext(context.Background(), c, des...)
+}
+
+// Exec the single statement with context.
+func (em *SessionExecManager) ExecContext(ctx context.Context, c CqlBuilder) error {
+	start := time.Now()
+	err := execStatement(ctx, c, em.driver())
+	em.observe(ctx, OpExec, c, start, false, err)
+	return err
+}
+
+// Exec the batch with context.
+func (em *SessionExecManager) ExecBatchContext(ctx context.Context, b *BatchBuilder) error {
+	start := time.Now()
+	err := execBatch(ctx, b, em.driver())
+	em.observe(ctx, OpExecBatch, b, start, false, err)
+	return err
+}
+
+//Exec query CAS with context.
+func (em *SessionExecManager) ExecCASContext(ctx context.Context, c CqlBuilder, dest ...interface{}) (bool, error) {
+	start := time.Now()
+	applied, err := execCAS(ctx, c, em.driver(), dest...)
+	em.observe(ctx, OpExecCAS, c, start, applied, err)
+	return applied, err
+}
+
+//Batch CAS with context.
+func (em *SessionExecManager) ExecBatchCASContext(ctx context.Context, b *BatchBuilder, dest ...interface{}) (applied bool, iter *cql.Iter, err error) {
+	applied, rows, err := em.ExecBatchCASRowsContext(ctx, b, dest...)
+	if err != nil {
+		return applied, nil, err
+	}
+
+	iter, err = toGocqlIter(rows)
+	return applied, iter, err
+}
+
+// run a query with context and fill back the result.
+func (em *SessionExecManager) ExecScanContext(ctx context.Context, c CqlBuilder, dest ...interface{}) error {
+	start := time.Now()
+	err := execScan(ctx, c, em.driver(), dest...)
+	em.observe(ctx, OpExecScan, c, start, false, err)
+	return err
+}
+
+// Return result set, the query is bound to the context. The *cql.Iter
+// can't be observed at Close, so the error of the first page is observed:
+// the iterator without rows and next page is closed to get its error, the
+// Close of the caller returns the same error.
+func (em *SessionExecManager) IterContext(ctx context.Context, c CqlBuilder, des ...interface{}) (*cql.Iter, error) {
+	start := time.Now()
+	rows, err := iterRows(ctx, c, em.driver())
+	if err != nil {
+		em.observe(ctx, OpIter, c, start, false, err)
+		return nil, err
+	}
+
+	iter, err := toGocqlIter(rows)
+	if err != nil {
+		em.observe(ctx, OpIter, c, start, false, err)
+		return nil, err
+	}
+
+	var iterErr error
+	if iter.NumRows() == 0 && len(iter.PageState()) == 0 {
+		iterErr = iter.Close()
+	}
+	em.observe(ctx, OpIter, c, start, false, iterErr)
+	return iter, nil
+}
+
+//Batch CAS, return the RowIterator.
+func (em *SessionExecManager) ExecBatchCASRows(b *BatchBuilder, dest ...interface{}) (bool, RowIterator, error) {
+	return em.ExecBatchCASRowsContext(context.Background(), b, dest...)
+}
+
+//Batch CAS with context, return the RowIterator.
+func (em *SessionExecManager) ExecBatchCASRowsContext(ctx context.Context, b *BatchBuilder, dest ...interface{}) (bool, RowIterator, error) {
+	start := time.Now()
+	applied, iter, err := execBatchCAS(ctx, b, em.driver(), dest...)
+	em.observe(ctx, OpExecBatchCAS, b, start, applied, err)
+	return applied, iter, err
+}
+
+// Return result set as RowIterator.
+func (em *SessionExecManager) IterRows(c CqlBuilder, des ...interface{}) (RowIterator, error) {
+	return em.IterRowsContext(context.Background(), c, des...)
+}
+
+// Return result set as RowIterator, the query is bound to the context.
+func (em *SessionExecManager) IterRowsContext(ctx context.Context, c CqlBuilder, des ...interface{}) (RowIterator, error) {
+	start := time.Now()
+	iter, err := iterRows(ctx, c, em.driver())
 	if err != nil {
+		em.observe(ctx, OpIter, c, start, false, err)
 		return nil, err
 	}
-	q := em.Session.Query(cqlstr, vals...)
 
-	if q != nil {
-		return q.Iter(), nil
-	}else {
-		return nil, ErrPreparingQueryFailed
+	// The error of the read surfaces at Close, the event is sent then.
+	return &observedIterator{RowIterator: iter, em: em, ctx: ctx, c: c, start: start}, nil
+}
+
+// The iterator sends the Iter event when it's closed, with the error of the
+// iteration.
+type observedIterator struct {
+	RowIterator
+	em     *SessionExecManager
+	ctx    context.Context
+	c      CqlBuilder
+	start  time.Time
+	closed bool
+}
+
+func (it *observedIterator) Close() error {
+	err := it.RowIterator.Close()
+	if !it.closed {
+		it.closed = true
+		it.em.observe(it.ctx, OpIter, it.c, it.start, false, err)
 	}
-}
\ No newline at end of file
+	return err
+}
+
+func (em *SessionExecManager) driver() Session {
+	if em.Driver != nil {
+		return em.Driver
+	}
+	return NewGocqlSession(em.Session)
+}
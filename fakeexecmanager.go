@@ -0,0 +1,754 @@
+package cqlbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cql "github.com/gocql/gocql"
+)
+
+var (
+	ErrFakeUnknownTable     = errors.New("cqlbuilder: fake table is not defined")
+	ErrFakeIterNotSupported = errors.New("cqlbuilder: fake can't create *cql.Iter, use IterRows")
+	ErrFakeTableExists      = errors.New("cqlbuilder: fake table already exists")
+)
+
+var (
+	_ ExecManager        = (*FakeExecManager)(nil)
+	_ ExecManagerV2      = (*FakeExecManager)(nil)
+	_ ExecManagerContext = (*FakeExecManager)(nil)
+	_ StructExecManager  = (*FakeExecManager)(nil)
+)
+
+// The in memory ExecManager for UT, it interprets the builders against the
+// tables kept in memory, so the test can assert on the state instead of the
+// CQL string. The tables must be defined with their primary key columns
+// before use.
+//
+// Supported: Eq/In in WHERE, IF NOT EXISTS, IF EXISTS and Eq/In in IF, the
+// TTL of insert/update, LIMIT and paging of select. The rows are returned in
+// insertion order, all rows are in the first page unless page size is set.
+// When a CAS statement is not applied, dest of ExecCAS receives the current
+// values of the inserted columns(IF NOT EXISTS) or the IF columns.
+// Example:
+//
+//	fake := NewFakeExecManager().DefineTable("users", "org_id", "user_id")
+//	fake.Exec(Insert("users").SetValue("org_id", 1).SetValue("user_id", 2).SetValue("name", "a"))
+//	row := fake.Get("users", 1, 2)
+type FakeExecManager struct {
+	// The clock used for TTL, time.Now if nil.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	tables map[string]*fakeTable
+}
+
+type fakeTable struct {
+	keys  []string
+	order []string
+	rows  map[string]*fakeRow
+}
+
+type fakeCell struct {
+	value   interface{}
+	expires time.Time
+}
+
+type fakeRow struct {
+	keyValues map[string]interface{}
+	// The row marker created by insert, the row exists while it's alive
+	// even all the cells are null.
+	marker *fakeCell
+	cells  map[string]fakeCell
+}
+
+// Create the fake exec manager.
+func NewFakeExecManager() *FakeExecManager {
+	return &FakeExecManager{
+		tables: map[string]*fakeTable{},
+	}
+}
+
+// Define the table with its primary key(partition and clustering) columns.
+func (f *FakeExecManager) DefineTable(table string, keys ...string) *FakeExecManager {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.defineTable(table, keys)
+	return f
+}
+
+// Define the table, the lock is held by the caller.
+func (f *FakeExecManager) defineTable(table string, keys []string) {
+	if f.tables == nil {
+		f.tables = map[string]*fakeTable{}
+	}
+	f.tables[table] = &fakeTable{
+		keys: keys,
+		rows: map[string]*fakeRow{},
+	}
+}
+
+// Get the live row by the primary key values(in the order of DefineTable),
+// nil if not found.
+func (f *FakeExecManager) Get(table string, keys ...interface{}) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := f.tables[table]
+	if t == nil {
+		return nil
+	}
+
+	r := t.rows[fakeKey(keys)]
+	if r == nil || !r.exists(f.now()) {
+		return nil
+	}
+	return r.toMap(f.now())
+}
+
+// Get all the live rows of the table.
+func (f *FakeExecManager) Rows(table string) []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := f.tables[table]
+	if t == nil {
+		return nil
+	}
+
+	now := f.now()
+	var rows []map[string]interface{}
+	for _, k := range t.order {
+		if r := t.rows[k]; r.exists(now) {
+			rows = append(rows, r.toMap(now))
+		}
+	}
+	return rows
+}
+
+// Exec the single statement
+func (f *FakeExecManager) Exec(c CqlBuilder) error {
+	if ct, ok := c.(*CreateTableBuilder); ok {
+		return f.createTable(ct)
+	}
+
+	_, err := f.ExecCAS(c)
+	return err
+}
+
+// Define the table by CREATE TABLE, the existing table is kept if IF NOT
+// EXISTS, otherwise ErrFakeTableExists like Cassandra's AlreadyExists.
+func (f *FakeExecManager) createTable(ct *CreateTableBuilder) error {
+	if _, _, err := ct.ToQuery(); err != nil {
+		return err
+	}
+
+	// The check and the define are one step, only one of the concurrent
+	// CREATE TABLE succeeds.
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.tables[ct.table]; exists {
+		if ct.ifNotExists {
+			return nil
+		}
+		return ErrFakeTableExists
+	}
+
+	f.defineTable(ct.table, append(append([]string(nil), ct.partitionKeys...), ct.clusteringKeys...))
+	return nil
+}
+
+// Exec the batch
+func (f *FakeExecManager) ExecBatch(b *BatchBuilder) error {
+	_, _, err := f.ExecBatchCASRows(b)
+	return err
+}
+
+// Exec query CAS
+func (f *FakeExecManager) ExecCAS(c CqlBuilder, dest ...interface{}) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w, err := f.prepareWrite(c)
+	if err != nil {
+		return false, err
+	}
+
+	now := f.now()
+	applied, current := w.check(now)
+	if !applied {
+		return false, ScanValues(current, dest)
+	}
+
+	w.apply(now)
+	return true, nil
+}
+
+// Batch CAS
+func (f *FakeExecManager) ExecBatchCAS(b *BatchBuilder, dest ...interface{}) (bool, *cql.Iter, error) {
+	applied, _, err := f.ExecBatchCASRows(b, dest...)
+	return applied, nil, err
+}
+
+// Batch CAS, the batch is applied only if all the conditions pass.
+func (f *FakeExecManager) ExecBatchCASRows(b *BatchBuilder, dest ...interface{}) (bool, RowIterator, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	writes := make([]*fakeWrite, 0, len(b.builders))
+	for _, c := range b.builders {
+		w, err := f.prepareWrite(c)
+		if err != nil {
+			return false, nil, err
+		}
+		writes = append(writes, w)
+	}
+
+	now := f.now()
+	for _, w := range writes {
+		if applied, current := w.check(now); !applied {
+			return false, NewSliceIterator(nil), ScanValues(current, dest)
+		}
+	}
+
+	for _, w := range writes {
+		w.apply(now)
+	}
+	return true, NewSliceIterator(nil), nil
+}
+
+// run a query and fill back the first row, cql.ErrNotFound if no rows.
+func (f *FakeExecManager) ExecScan(c CqlBuilder, dest ...interface{}) error {
+	iter, err := f.IterRows(c)
+	if err != nil {
+		return err
+	}
+
+	if !iter.Scan(dest...) {
+		if err := iter.Close(); err != nil {
+			return err
+		}
+		return cql.ErrNotFound
+	}
+	return iter.Close()
+}
+
+// The fake can't create *cql.Iter, always return ErrFakeIterNotSupported.
+func (f *FakeExecManager) Iter(c CqlBuilder, des ...interface{}) (*cql.Iter, error) {
+	return nil, ErrFakeIterNotSupported
+}
+
+// Return result set of the select.
+func (f *FakeExecManager) IterRows(c CqlBuilder, des ...interface{}) (RowIterator, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sel, ok := c.(*SelectBuilder)
+	if !ok {
+		return nil, fmt.Errorf("cqlbuilder: fake can't query %T", c)
+	}
+
+	if err := sel.validate(); err != nil {
+		return nil, err
+	}
+
+	t := f.tables[sel.table]
+	if t == nil {
+		return nil, ErrFakeUnknownTable
+	}
+
+	now := f.now()
+	iter := NewSliceIterator(sel.colums)
+	pageSize, offset := 0, 0
+	if o := sel.options; o != nil {
+		pageSize = o.pageSize
+		if len(o.pageState) > 0 {
+			var err error
+			if offset, err = strconv.Atoi(string(o.pageState)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	matched := 0
+	for _, k := range t.order {
+		r := t.rows[k]
+		if !r.exists(now) {
+			continue
+		}
+
+		match, err := r.match(sel.whereConditions, now)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			continue
+		}
+
+		matched++
+		if matched <= offset {
+			continue
+		}
+		if sel.limitNumber > 0 && matched > sel.limitNumber {
+			break
+		}
+		if pageSize > 0 && len(iter.Rows) == pageSize {
+			// The page state of the fake is the offset of the next page.
+			iter.NextPageState = []byte(strconv.Itoa(offset + pageSize))
+			break
+		}
+
+		row := make([]interface{}, len(sel.colums))
+		for i, col := range sel.colums {
+			row[i] = r.get(col, now)
+		}
+		iter.Rows = append(iter.Rows, row)
+	}
+
+	return iter, nil
+}
+
+// Exec the single statement, fail if the context is done.
+func (f *FakeExecManager) ExecContext(ctx context.Context, c CqlBuilder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Exec(c)
+}
+
+// Exec the batch, fail if the context is done.
+func (f *FakeExecManager) ExecBatchContext(ctx context.Context, b *BatchBuilder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.ExecBatch(b)
+}
+
+// Exec query CAS, fail if the context is done.
+func (f *FakeExecManager) ExecCASContext(ctx context.Context, c CqlBuilder, dest ...interface{}) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return f.ExecCAS(c, dest...)
+}
+
+// Batch CAS, fail if the context is done.
+func (f *FakeExecManager) ExecBatchCASContext(ctx context.Context, b *BatchBuilder, dest ...interface{}) (bool, *cql.Iter, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+	return f.ExecBatchCAS(b, dest...)
+}
+
+// Run the query and fill back the result, fail if the context is done.
+func (f *FakeExecManager) ExecScanContext(ctx context.Context, c CqlBuilder, dest ...interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.ExecScan(c, dest...)
+}
+
+// The fake can't create *cql.Iter, always return ErrFakeIterNotSupported.
+func (f *FakeExecManager) IterContext(ctx context.Context, c CqlBuilder, des ...interface{}) (*cql.Iter, error) {
+	return nil, ErrFakeIterNotSupported
+}
+
+// Return result set of the select, fail if the context is done.
+func (f *FakeExecManager) IterRowsContext(ctx context.Context, c CqlBuilder, des ...interface{}) (RowIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.IterRows(c, des...)
+}
+
+func (f *FakeExecManager) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
+
+// The resolved write statement.
+type fakeWrite struct {
+	table       *fakeTable
+	keys        [][]interface{}
+	ifNotExists bool
+	ifConds     []conditionBuilder
+	// The columns returned when the CAS is not applied.
+	casColumns []string
+	// Create the row if missing.
+	create bool
+	// Delete the whole row.
+	deleteRow bool
+	mutate    func(r *fakeRow, now time.Time)
+}
+
+// Resolve the builder into the write.
+func (f *FakeExecManager) prepareWrite(c CqlBuilder) (*fakeWrite, error) {
+	if _, _, err := c.ToQuery(); err != nil {
+		return nil, err
+	}
+
+	table, _ := describeStatement(c)
+	t := f.tables[table]
+	if t == nil {
+		return nil, ErrFakeUnknownTable
+	}
+
+	w := &fakeWrite{table: t}
+	var err error
+
+	switch b := c.(type) {
+	case *InsertBuilder:
+		conds := make([]conditionBuilder, 0, len(b.colums))
+		for i, col := range b.colums {
+			conds = append(conds, Eq(col, b.values[i]))
+		}
+		if w.keys, err = t.resolveKeys(conds, true); err != nil {
+			return nil, err
+		}
+
+		w.create = true
+		w.ifNotExists = b.ifNotExists
+		w.casColumns = b.colums
+		ttl := b.ttl
+		w.mutate = func(r *fakeRow, now time.Time) {
+			expires := expiry(now, ttl)
+			r.marker = &fakeCell{expires: expires}
+			for i, col := range b.colums {
+				if !t.isKey(col) {
+					r.cells[col] = fakeCell{value: b.values[i], expires: expires}
+				}
+			}
+		}
+
+	case *UpdateBuilder:
+		if w.keys, err = t.resolveKeys(b.whereConditions, false); err != nil {
+			return nil, err
+		}
+
+		w.create = true
+		w.ifConds = b.ifConditions
+		ttl := b.ttl
+		w.mutate = func(r *fakeRow, now time.Time) {
+			expires := expiry(now, ttl)
+			for i, col := range b.colums {
+				r.cells[col] = fakeCell{value: b.values[i], expires: expires}
+			}
+		}
+
+	case *DeleteBuilder:
+		if w.keys, err = t.resolveKeys(b.whereConditions, false); err != nil {
+			return nil, err
+		}
+
+		w.ifConds = b.ifConditions
+		if len(b.colums) == 0 {
+			w.deleteRow = true
+		}
+		w.mutate = func(r *fakeRow, now time.Time) {
+			for _, col := range b.colums {
+				delete(r.cells, col)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("cqlbuilder: fake can't exec %T", c)
+	}
+
+	for _, cond := range w.ifConds {
+		if col := conditionColumn(cond); col != "" {
+			w.casColumns = append(w.casColumns, col)
+		}
+	}
+
+	return w, nil
+}
+
+// Check the IF clause of the write, return the current values of the CAS
+// columns if not applied.
+func (w *fakeWrite) check(now time.Time) (bool, []interface{}) {
+	if !w.ifNotExists && len(w.ifConds) == 0 {
+		return true, nil
+	}
+
+	for _, key := range w.keys {
+		r := w.table.rows[fakeKey(key)]
+		exists := r.exists(now)
+
+		applied := true
+		if w.ifNotExists {
+			applied = !exists
+		} else if !exists {
+			applied = false
+		} else if match, err := r.match(w.ifConds, now); err != nil || !match {
+			applied = false
+		}
+
+		if !applied {
+			current := make([]interface{}, len(w.casColumns))
+			if exists {
+				for i, col := range w.casColumns {
+					current[i] = r.get(col, now)
+				}
+			}
+			return false, current
+		}
+	}
+
+	return true, nil
+}
+
+// Apply the write to all the rows.
+func (w *fakeWrite) apply(now time.Time) {
+	t := w.table
+	for _, key := range w.keys {
+		k := fakeKey(key)
+
+		if w.deleteRow {
+			if _, ok := t.rows[k]; ok {
+				delete(t.rows, k)
+				for i, o := range t.order {
+					if o == k {
+						t.order = append(t.order[:i], t.order[i+1:]...)
+						break
+					}
+				}
+			}
+			continue
+		}
+
+		r := t.rows[k]
+		if r == nil {
+			if !w.create {
+				continue
+			}
+			r = &fakeRow{
+				keyValues: map[string]interface{}{},
+				cells:     map[string]fakeCell{},
+			}
+			for i, col := range t.keys {
+				r.keyValues[col] = key[i]
+			}
+			t.rows[k] = r
+			t.order = append(t.order, k)
+		}
+
+		w.mutate(r, now)
+	}
+}
+
+func (t *fakeTable) isKey(col string) bool {
+	for _, k := range t.keys {
+		if k == col {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve the primary key tuples from the Eq/In conditions on all the key
+// columns. Other conditions are not allowed unless ignoreOthers.
+func (t *fakeTable) resolveKeys(conds []conditionBuilder, ignoreOthers bool) ([][]interface{}, error) {
+	values := make(map[string][]interface{}, len(t.keys))
+	for _, cond := range conds {
+		col := conditionColumn(cond)
+		if !t.isKey(col) {
+			if ignoreOthers {
+				continue
+			}
+			return nil, fmt.Errorf("cqlbuilder: fake only support primary key in WHERE, got %s", col)
+		}
+
+		switch c := cond.(type) {
+		case *eqBuilder:
+			values[col] = []interface{}{c.value}
+		case *inBuilder:
+			values[col] = toSlice(c.values)
+		}
+	}
+
+	keys := [][]interface{}{nil}
+	for _, col := range t.keys {
+		vs, ok := values[col]
+		if !ok {
+			return nil, fmt.Errorf("cqlbuilder: fake need primary key column %s", col)
+		}
+
+		next := make([][]interface{}, 0, len(keys)*len(vs))
+		for _, k := range keys {
+			for _, v := range vs {
+				next = append(next, append(append([]interface{}(nil), k...), v))
+			}
+		}
+		keys = next
+	}
+
+	return keys, nil
+}
+
+func (r *fakeRow) exists(now time.Time) bool {
+	if r == nil {
+		return false
+	}
+	if r.marker != nil && r.marker.alive(now) {
+		return true
+	}
+	for _, c := range r.cells {
+		if c.alive(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeRow) get(col string, now time.Time) interface{} {
+	if v, ok := r.keyValues[col]; ok {
+		return v
+	}
+	if c, ok := r.cells[col]; ok && c.alive(now) {
+		return c.value
+	}
+	return nil
+}
+
+func (r *fakeRow) toMap(now time.Time) map[string]interface{} {
+	m := make(map[string]interface{}, len(r.keyValues)+len(r.cells))
+	for col, v := range r.keyValues {
+		m[col] = v
+	}
+	for col, c := range r.cells {
+		if c.alive(now) {
+			m[col] = c.value
+		}
+	}
+	return m
+}
+
+// Evaluate the conditions against the row.
+func (r *fakeRow) match(conds []conditionBuilder, now time.Time) (bool, error) {
+	for _, cond := range conds {
+		switch c := cond.(type) {
+		case *existsBuilder:
+			// The row exists when we get here.
+		case *eqBuilder:
+			if !ValuesEqual(r.get(c.column, now), c.value) {
+				return false, nil
+			}
+		case *inBuilder:
+			found := false
+			for _, v := range toSlice(c.values) {
+				if ValuesEqual(r.get(c.column, now), v) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("cqlbuilder: fake doesn't support condition %T", cond)
+		}
+	}
+	return true, nil
+}
+
+func (c fakeCell) alive(now time.Time) bool {
+	return c.expires.IsZero() || now.Before(c.expires)
+}
+
+func expiry(now time.Time, ttl int) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration(ttl) * time.Second)
+}
+
+// The column of the Eq/In condition, empty for others.
+func conditionColumn(cond conditionBuilder) string {
+	switch c := cond.(type) {
+	case *eqBuilder:
+		return c.column
+	case *inBuilder:
+		return c.column
+	}
+	return ""
+}
+
+// The key of the primary key values. The type is part of the key so 1 and
+// "1" are different keys, the numbers are keyed by value regardless of the
+// type as ValuesEqual does.
+func fakeKey(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if v != nil && isNumeric(reflect.ValueOf(v).Kind()) {
+			parts[i] = "number:" + fmt.Sprint(v)
+		} else {
+			parts[i] = fmt.Sprintf("%T:%#v", v, v)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// Convert the slice value of In condition to []interface{}.
+func toSlice(vs interface{}) []interface{} {
+	rv := reflect.ValueOf(vs)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{vs}
+	}
+
+	ret := make([]interface{}, rv.Len())
+	for i := range ret {
+		ret[i] = rv.Index(i).Interface()
+	}
+	return ret
+}
+
+// Compare the values, the numeric values are compared by value regardless
+// of the type. It's how the fake and the mocks of cqlbuildertest match the
+// bound values.
+func ValuesEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if isNumeric(av.Kind()) && isNumeric(bv.Kind()) {
+		return av.Convert(reflect.TypeOf(float64(0))).Float() == bv.Convert(reflect.TypeOf(float64(0))).Float()
+	}
+	return false
+}
+
+// Scan the values into dest like the row of SliceIterator, dest can be less
+// than the values.
+func ScanValues(values []interface{}, dest []interface{}) error {
+	for i := range dest {
+		if i >= len(values) {
+			break
+		}
+		if err := assignValue(dest[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run the select and scan the first row into the struct.
+func (f *FakeExecManager) ExecScanStruct(sel *SelectBuilder, dest interface{}) error {
+	return SelectOne(f, sel, dest)
+}
+
+// Run the select and append all the rows to the slice of struct.
+func (f *FakeExecManager) IterAll(sel *SelectBuilder, dest interface{}) error {
+	return SelectAll(f, sel, dest)
+}
@@ -0,0 +1,134 @@
+package cqlbuilder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeInsertSelect(t *testing.T) {
+	fake := NewFakeExecManager().DefineTable("users", "org", "id")
+
+	fake.Exec(Insert("users").SetValue("org", 1).SetValue("id", "a").SetValue("name", "Alice"))
+	fake.Exec(Insert("users").SetValue("org", 1).SetValue("id", "b").SetValue("name", "Bob"))
+	fake.Exec(Insert("users").SetValue("org", 2).SetValue("id", "c").SetValue("name", "Carl"))
+
+	var name string
+	err := fake.ExecScan(Select("users").AddColumn("name").Where(Eq("org", 1)).Where(Eq("id", "b")), &name)
+	if err != nil || name != "Bob" {
+		t.Logf("err %v name %s", err, name)
+		t.FailNow()
+	}
+
+	iter, err := fake.IterRows(Select("users").AddColumns("id", "name").Where(In("org", []int{1, 2})).SetLimit(2))
+	if err != nil || iter.NumRows() != 2 {
+		t.Logf("err %v iter %v", err, iter)
+		t.FailNow()
+	}
+
+	if row := fake.Get("users", 2, "c"); row == nil || row["name"] != "Carl" {
+		t.Logf("row %v", row)
+		t.FailNow()
+	}
+
+	// 1 and "1" are different keys, the numbers are keyed by value.
+	fake.Exec(Insert("users").SetValue("org", "1").SetValue("id", "a").SetValue("name", "Text"))
+	if row := fake.Get("users", int64(1), "a"); row == nil || row["name"] != "Alice" || len(fake.Rows("users")) != 4 {
+		t.Logf("row %v rows %v", row, fake.Rows("users"))
+		t.FailNow()
+	}
+}
+
+func TestFakeCAS(t *testing.T) {
+	fake := NewFakeExecManager().DefineTable("t", "id")
+
+	applied, err := fake.ExecCAS(Insert("t").SetValue("id", 1).SetValue("version", 1).IfNotExists(true))
+	if !applied || err != nil {
+		t.Logf("applied %v err %v", applied, err)
+		t.FailNow()
+	}
+
+	var id, version int
+	applied, err = fake.ExecCAS(Insert("t").SetValue("id", 1).SetValue("version", 5).IfNotExists(true), &id, &version)
+	if applied || err != nil || version != 1 {
+		t.Logf("applied %v err %v version %d", applied, err, version)
+		t.FailNow()
+	}
+
+	applied, _ = fake.ExecCAS(Update("t").SetValue("version", 2).Where(Eq("id", 1)).If(Eq("version", 3)), &version)
+	if applied || version != 1 {
+		t.Logf("applied %v version %d", applied, version)
+		t.FailNow()
+	}
+
+	applied, _ = fake.ExecCAS(Update("t").SetValue("version", 2).Where(Eq("id", 1)).If(Eq("version", 1)))
+	if !applied || fake.Get("t", 1)["version"] != 2 {
+		t.Logf("applied %v row %v", applied, fake.Get("t", 1))
+		t.FailNow()
+	}
+
+	applied, _ = fake.ExecCAS(Delete("t").Where(Eq("id", 2)).If(Exists()))
+	if applied {
+		t.Logf("Delete not exists row should not apply")
+		t.FailNow()
+	}
+
+	fake.Exec(Delete("t").Where(Eq("id", 1)))
+	if fake.Get("t", 1) != nil {
+		t.Logf("row not deleted")
+		t.FailNow()
+	}
+}
+
+func TestFakeBatchCAS(t *testing.T) {
+	fake := NewFakeExecManager().DefineTable("t", "id")
+	fake.Exec(Insert("t").SetValue("id", 1).SetValue("v", "a"))
+
+	batch := StartBatch().
+		Add(Insert("t").SetValue("id", 2).SetValue("v", "b").IfNotExists(true)).
+		Add(Update("t").SetValue("v", "c").Where(Eq("id", 1)).If(Eq("v", "x")))
+	applied, _, err := fake.ExecBatchCASRows(batch)
+	if applied || err != nil || fake.Get("t", 2) != nil {
+		t.Logf("applied %v err %v", applied, err)
+		t.FailNow()
+	}
+}
+
+func TestFakeTTL(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFakeExecManager().DefineTable("t", "id")
+	fake.Now = func() time.Time { return now }
+
+	fake.Exec(Insert("t").SetValue("id", 1).SetValue("v", "a").SetTtl(10))
+	if fake.Get("t", 1) == nil {
+		t.Logf("row should be alive")
+		t.FailNow()
+	}
+
+	now = now.Add(11 * time.Second)
+	if fake.Get("t", 1) != nil || len(fake.Rows("t")) != 0 {
+		t.Logf("row should expire")
+		t.FailNow()
+	}
+}
+
+func TestFakeUnknownTable(t *testing.T) {
+	fake := NewFakeExecManager()
+	if err := fake.Exec(Insert("t").SetValue("id", 1)); err != ErrFakeUnknownTable {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+}
+
+func TestValuesEqual(t *testing.T) {
+	if !ValuesEqual(1, int64(1)) || !ValuesEqual(1.0, 1) || ValuesEqual(1, "1") || ValuesEqual(nil, 0) {
+		t.Logf("numeric values are compared by value")
+		t.FailNow()
+	}
+
+	var id int64
+	var name string
+	if err := ScanValues([]interface{}{1, "a", true}, []interface{}{&id, &name}); err != nil || id != 1 || name != "a" {
+		t.Logf("err %v id %d name %s", err, id, name)
+		t.FailNow()
+	}
+}
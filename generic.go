@@ -0,0 +1,133 @@
+package cqlbuilder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	cql "github.com/gocql/gocql"
+)
+
+// Returned by Get if no rows, it's the same error as gocql's.
+var ErrNotFound = cql.ErrNotFound
+
+// Run the select and return all the rows as []T. T is the `cql` tagged
+// struct or the pointer to it. If the select has no columns, the columns of
+// T are used.
+// Example:
+//
+//	users, err := cb.Query[User](mgr, cb.Select(userTbl).Where(cb.Eq(orgIdCol, orgid)))
+func Query[T any](mgr ExecManager, sel *SelectBuilder) ([]T, error) {
+	var rows []T
+	err := Each(mgr, sel, func(row T) error {
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
+
+// Run the select and return the first row, ErrNotFound if no rows.
+func Get[T any](mgr ExecManager, sel *SelectBuilder) (T, error) {
+	var ret T
+	found := false
+	err := Each(mgr, sel, func(row T) error {
+		ret = row
+		found = true
+		return errStopEach
+	})
+
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+	return ret, err
+}
+
+// Used by Get to stop at the first row.
+var errStopEach = errors.New("cqlbuilder: stop each")
+
+// Run the select and call fn with every row, stop at the first error
+// returned by fn.
+func Each[T any](mgr ExecManager, sel *SelectBuilder, fn func(row T) error) error {
+	var zero T
+	t := reflect.TypeOf(&zero).Elem()
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return errNotStruct
+	}
+
+	iter, err := iterOf(mgr, sel.withStructColumns(reflect.New(t).Interface()))
+	if err != nil {
+		return err
+	}
+
+	for {
+		row := reflect.New(t)
+		ok, err := ScanStruct(iter, row.Interface())
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		var v T
+		if isPtr {
+			v = row.Interface().(T)
+		} else {
+			v = row.Elem().Interface().(T)
+		}
+
+		if err := fn(v); err != nil {
+			iter.Close()
+			if err == errStopEach {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return iter.Close()
+}
+
+// The exec manager returns RowIterator with the context.
+type rowsContextIterator interface {
+	IterRowsContext(ctx context.Context, c CqlBuilder, des ...interface{}) (RowIterator, error)
+}
+
+// Get the RowIterator with the context from the exec manager,
+// IterRowsContext is used if the manager implements it.
+func iterOfContext(ctx context.Context, mgr ExecManagerContext, c CqlBuilder) (RowIterator, error) {
+	if rc, ok := mgr.(rowsContextIterator); ok {
+		return rc.IterRowsContext(ctx, c)
+	}
+
+	iter, err := mgr.IterContext(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if iter == nil {
+		return nil, ErrPreparingQueryFailed
+	}
+	return iter, nil
+}
+
+// Get the RowIterator from the exec manager, IterRows is used if the
+// manager implements ExecManagerV2.
+func iterOf(mgr ExecManager, sel *SelectBuilder) (RowIterator, error) {
+	if v2, ok := mgr.(ExecManagerV2); ok {
+		return v2.IterRows(sel)
+	}
+
+	iter, err := mgr.Iter(sel)
+	if err != nil {
+		return nil, err
+	}
+	if iter == nil {
+		return nil, ErrPreparingQueryFailed
+	}
+	return iter, nil
+}
@@ -0,0 +1,57 @@
+package cqlbuilder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGenericQuery(t *testing.T) {
+	fake := NewFakeExecManager().DefineTable("t", "org", "id")
+	fake.Exec(Insert("t").SetValue("org", 1).SetValue("id", 1).SetValue("name", "a"))
+	fake.Exec(Insert("t").SetValue("org", 1).SetValue("id", 2).SetValue("name", "b"))
+
+	type row struct {
+		ID   int    `cql:"id"`
+		Name string `cql:"name"`
+	}
+
+	rows, err := Query[row](fake, Select("t").Where(Eq("org", 1)))
+	if err != nil || len(rows) != 2 || rows[1].Name != "b" {
+		t.Logf("err %v rows %v", err, rows)
+		t.FailNow()
+	}
+
+	// The select can be reused for the other struct.
+	sel := Select("t").Where(Eq("org", 1))
+	Query[row](fake, sel)
+	type nameRow struct {
+		Name string `cql:"name"`
+	}
+	names, err := Query[nameRow](fake, sel)
+	if err != nil || len(names) != 2 || len(sel.colums) != 0 {
+		t.Logf("err %v rows %v columns %v", err, names, sel.colums)
+		t.FailNow()
+	}
+
+	r, err := Get[*row](fake, Select("t").Where(Eq("org", 1)).Where(Eq("id", 2)))
+	if err != nil || r.Name != "b" {
+		t.Logf("err %v row %v", err, r)
+		t.FailNow()
+	}
+
+	if _, err := Get[row](fake, Select("t").Where(Eq("org", 2))); err != ErrNotFound {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	boom := errors.New("boom")
+	n := 0
+	err = Each(fake, Select("t").Where(Eq("org", 1)), func(r row) error {
+		n++
+		return boom
+	})
+	if err != boom || n != 1 {
+		t.Logf("err %v n %d", err, n)
+		t.FailNow()
+	}
+}
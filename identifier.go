@@ -0,0 +1,252 @@
+package cqlbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// The error of the invalid table/column name.
+type IdentifierError struct {
+	Name   string
+	Reason string
+}
+
+func (e *IdentifierError) Error() string {
+	return fmt.Sprintf("cqlbuilder: invalid identifier %q: %s", e.Name, e.Reason)
+}
+
+var (
+	// The identifier which can be used without quote, Cassandra folds it to
+	// the lower case.
+	plainIdentifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	// The name of keyspace and table.
+	tableIdentifier = regexp.MustCompile(`^[A-Za-z0-9_]{1,48}$`)
+	// The function call selector like token(a,b), count(*), writetime(c).
+	functionSelector = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)\((.*)\)$`)
+	// The element of the collection like l[0], m['k'], s[?], or the field of
+	// the UDT like addr.city, the column may be quoted. The index or key is
+	// the bind marker, integer, string or uuid literal.
+	elementSelector = regexp.MustCompile(`^("(?:[^"]|"")+"|[A-Za-z][A-Za-z0-9_]*)` +
+		`(\[(?:\?|:[A-Za-z_][A-Za-z0-9_]*|-?[0-9]+|'(?:[^']|'')*'|[0-9A-Fa-f]{8}-[0-9A-Fa-f-]{27})\]|\.("(?:[^"]|"")+"|[A-Za-z][A-Za-z0-9_]*))$`)
+)
+
+// The reserved CQL keywords, they are quoted when used as the identifier.
+// key is not reserved but it's easy to clash with, quoting the lower case
+// name is harmless.
+var cqlKeywords = map[string]bool{}
+
+func init() {
+	for _, k := range strings.Fields(`
+		add allow alter and apply asc authorize batch begin by columnfamily create
+		default delete desc describe drop entries execute from full grant if in index
+		infinity insert into is key keyspace limit materialized mbean mbeans modify nan
+		norecursive not null of on or order primary rename replace revoke schema select
+		set table to token truncate unlogged unset update use using view where with`) {
+		cqlKeywords[k] = true
+	}
+}
+
+// Quote the column name if required: the keyword and the name with special
+// characters are double quoted, the embedded quotes are escaped. The mixed
+// case name is kept unquoted, so Cassandra folds it to the lower case as
+// before; quote it by the caller, e.g. `"Col1"`, to make it case sensitive.
+// The name already quoted is kept as is.
+func QuoteIdentifier(name string) (string, error) {
+	if len(name) == 0 {
+		return "", &IdentifierError{Name: name, Reason: "empty name"}
+	}
+	if !utf8.ValidString(name) {
+		return "", &IdentifierError{Name: name, Reason: "invalid utf8"}
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			return "", &IdentifierError{Name: name, Reason: "control character"}
+		}
+	}
+
+	if plainIdentifier.MatchString(name) {
+		// The keyword is quoted in the lower case, the same column as the
+		// unquoted name.
+		if lower := strings.ToLower(name); cqlKeywords[lower] {
+			return `"` + lower + `"`, nil
+		}
+		return name, nil
+	}
+
+	if isQuoted(name) {
+		return name, nil
+	}
+
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`, nil
+}
+
+// Whether the name is a well formed quoted identifier.
+func isQuoted(name string) bool {
+	if len(name) < 3 || name[0] != '"' || name[len(name)-1] != '"' {
+		return false
+	}
+	return !strings.Contains(strings.Replace(name[1:len(name)-1], `""`, "", -1), `"`)
+}
+
+// The name as Cassandra stores it: the unquoted name is folded to lower
+// case, the quoted one is unquoted.
+func canonicalName(name string) string {
+	if isQuoted(name) {
+		return strings.Replace(name[1:len(name)-1], `""`, `"`, -1)
+	}
+	if plainIdentifier.MatchString(name) {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+func canonicalNames(names []string) []string {
+	ret := make([]string, len(names))
+	for i, n := range names {
+		ret[i] = canonicalName(n)
+	}
+	return ret
+}
+
+// Quote the keyspace or table name, the keyspace qualified ks.table is
+// supported. Cassandra only allows alphanumeric and underscore, up to 48
+// characters, in keyspace and table names.
+func QuoteTable(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return "", &IdentifierError{Name: name, Reason: "too many dots"}
+	}
+
+	for i, p := range parts {
+		raw := p
+		if isQuoted(p) {
+			raw = p[1 : len(p)-1]
+		}
+		if !tableIdentifier.MatchString(raw) {
+			return "", &IdentifierError{Name: name, Reason: "keyspace/table need 1-48 alphanumeric or underscore characters"}
+		}
+
+		if !isQuoted(p) {
+			q, err := QuoteIdentifier(p)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = q
+		}
+	}
+
+	return strings.Join(parts, "."), nil
+}
+
+// Quote the column or the selector used in the builders: the column name,
+// the element of the collection or UDT, * and the function call on columns
+// like token(a,b) or count(*).
+func quoteColumn(name string) (string, error) {
+	if name == "*" {
+		return name, nil
+	}
+	if q, ok, err := quoteElement(name); ok {
+		return q, err
+	}
+
+	if m := functionSelector.FindStringSubmatch(name); m != nil && !isQuoted(name) {
+		if len(strings.TrimSpace(m[2])) == 0 {
+			return m[1] + leftPar + rightPar, nil
+		}
+		args := strings.Split(m[2], comma)
+		for i, arg := range args {
+			q, err := quoteColumn(strings.TrimSpace(arg))
+			if err != nil {
+				return "", err
+			}
+			args[i] = q
+		}
+		return m[1] + leftPar + strings.Join(args, comma) + rightPar, nil
+	}
+
+	return QuoteIdentifier(name)
+}
+
+// Quote the column written by INSERT, UPDATE and DELETE, the element of the
+// collection or UDT is supported. * and the function call selector are
+// rejected.
+func quoteTarget(name string) (string, error) {
+	if name == "*" || (functionSelector.MatchString(name) && !isQuoted(name)) {
+		return "", &IdentifierError{Name: name, Reason: "only the column can be written"}
+	}
+	if q, ok, err := quoteElement(name); ok {
+		return q, err
+	}
+	return QuoteIdentifier(name)
+}
+
+// Quote the column of the element selector l[0] or addr.city, the index or
+// key in [] is kept as is. Return false if it's not the element selector.
+func quoteElement(name string) (string, bool, error) {
+	m := elementSelector.FindStringSubmatch(name)
+	if m == nil || isQuoted(name) {
+		return "", false, nil
+	}
+
+	column, err := QuoteIdentifier(m[1])
+	if err != nil {
+		return "", true, err
+	}
+	if len(m[3]) == 0 {
+		return column + m[2], true, nil
+	}
+	field, err := QuoteIdentifier(m[3])
+	if err != nil {
+		return "", true, err
+	}
+	return column + "." + field, true, nil
+}
+
+// The column of the selector, l of l[0] and addr of addr.city.
+func elementColumn(name string) string {
+	if m := elementSelector.FindStringSubmatch(name); m != nil && !isQuoted(name) {
+		return m[1]
+	}
+	return name
+}
+
+// Quote all the written columns.
+func quoteTargets(names []string) ([]string, error) {
+	ret := make([]string, len(names))
+	for i, n := range names {
+		q, err := quoteTarget(n)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = q
+	}
+	return ret, nil
+}
+
+// Quote all the columns.
+func quoteColumns(names []string) ([]string, error) {
+	ret := make([]string, len(names))
+	for i, n := range names {
+		q, err := quoteColumn(n)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = q
+	}
+	return ret, nil
+}
+
+// Quote all the names, the function call selector is not allowed.
+func quoteIdentifiers(names []string) ([]string, error) {
+	ret := make([]string, len(names))
+	for i, n := range names {
+		q, err := QuoteIdentifier(n)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = q
+	}
+	return ret, nil
+}
@@ -0,0 +1,121 @@
+package cqlbuilder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	cases := map[string]string{
+		"col1":       "col1",
+		"userId":     "userId",
+		"token":      `"token"`,
+		"Token":      `"token"`,
+		"key":        `"key"`,
+		`we"ird`:     `"we""ird"`,
+		"a b":        `"a b"`,
+		`"Quoted"`:   `"Quoted"`,
+		"token(a,B)": "token(a,B)",
+		"count(*)":   "count(*)",
+		"*":          "*",
+		"m['k']":     "m['k']",
+		"Addr.City":  "Addr.City",
+		"key.order":  `"key"."order"`,
+	}
+
+	for name, expected := range cases {
+		q, err := quoteColumn(name)
+		if err != nil || q != expected {
+			t.Logf("name %s quoted %s err %v, expected %s", name, q, err, expected)
+			t.FailNow()
+		}
+	}
+
+	for _, name := range []string{"", "a\nb", "\xff", "token(a,)"} {
+		if _, err := quoteColumn(name); err == nil {
+			t.Logf("err expected for %q", name)
+			t.FailNow()
+		}
+	}
+}
+
+func TestQuoteTable(t *testing.T) {
+	cases := map[string]string{
+		"users":       "users",
+		"ks.users":    "ks.users",
+		"MyKs.Users":  "MyKs.Users",
+		"ks.table":    `ks."table"`,
+		"ks.Table":    `ks."table"`,
+		`ks."Events"`: `ks."Events"`,
+	}
+
+	for name, expected := range cases {
+		q, err := QuoteTable(name)
+		if err != nil || q != expected {
+			t.Logf("name %s quoted %s err %v, expected %s", name, q, err, expected)
+			t.FailNow()
+		}
+	}
+
+	for _, name := range []string{"", "a.b.c", "ks.", "users; DROP TABLE x", `"a""b"`} {
+		_, err := QuoteTable(name)
+		if _, ok := err.(*IdentifierError); !ok {
+			t.Logf("IdentifierError expected for %q, got %v", name, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestBuilderRejectInvalidName(t *testing.T) {
+	_, _, err := Select("users").AddColumn("id").Where(Eq("id) OR (1=1", 1)).ToQuery()
+	if err != nil {
+		t.Logf("the odd column is quoted, err %v", err)
+		t.FailNow()
+	}
+
+	_, _, err = Insert("users(id) VALUES(1);--").SetValue("id", 1).ToQuery()
+	if _, ok := err.(*IdentifierError); !ok {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	str, _, err := Update("ks.Users").SetValue("order", 1).Where(Eq("userId", 1)).ToQuery()
+	if err != nil || str != ` UPDATE ks.Users SET "order" =?  WHERE userId=?` {
+		t.Logf("str %s err %v", str, err)
+		t.FailNow()
+	}
+
+	// The element of the collection or UDT is written, the column is quoted.
+	str, _, err = Update("t").SetValue("l[0]", 1).SetValue("key['k''s']", 2).SetValue(`"Addr".zip`, 3).Where(Eq("id", 1)).ToQuery()
+	if err != nil || str != ` UPDATE t SET l[0] =? ,"key"['k''s'] =? ,"Addr".zip =?  WHERE id=?` {
+		t.Logf("str %s err %v", str, err)
+		t.FailNow()
+	}
+	str, _, err = Delete("t").DeleteColumn("m['k']").DeleteColumn("l[?]").Where(Eq("id", 1)).ToQuery()
+	if err != nil || str != " DELETE m['k'],l[?] FROM t WHERE id=?" {
+		t.Logf("str %s err %v", str, err)
+		t.FailNow()
+	}
+
+	// The odd index is not the element, it's quoted as the column.
+	str, _, err = Update("t").SetValue("l[0]=1, admin=true, x[0]", 1).Where(Eq("id", 1)).ToQuery()
+	if err != nil || !strings.HasPrefix(str, ` UPDATE t SET "l[0]=1, admin=true, x[0]" =?`) {
+		t.Logf("str %s err %v", str, err)
+		t.FailNow()
+	}
+
+	// Only the columns can be written.
+	for _, b := range []CqlBuilder{
+		Insert("users").SetValue("*", 1),
+		Insert("users").SetValue("token(id)", 1),
+		Update("users").SetValue("writetime(a)", 1).Where(Eq("id", 1)),
+		Delete("users").DeleteColumn("*").Where(Eq("id", 1)),
+		Delete("users").DeleteColumn("token(id)").Where(Eq("id", 1)),
+	} {
+		_, _, err := b.ToQuery()
+		if _, ok := err.(*IdentifierError); !ok {
+			t.Logf("err %v", err)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	createIndex = " CREATE "
+	dropIndex   = " DROP INDEX "
+
+	// The index class of SASI.
+	SASIIndexClass = "org.apache.cassandra.index.sasi.SASIIndex"
+)
+
+// The target of the collection index.
+const (
+	indexValues  = "VALUES"
+	indexKeys    = "KEYS"
+	indexEntries = "ENTRIES"
+	indexFull    = "FULL"
+)
+
+// The type of create index builder which wrap the CREATE INDEX statement.
+// Example:
+//
+//	CreateIndex("users_by_email", "users").On("email")
+//	CreateIndex("", "users").OnKeys("attrs")
+//	CreateIndex("users_name_sasi", "users").On("name").SASI(map[string]string{"mode": "CONTAINS"})
+type CreateIndexBuilder struct {
+	name        string
+	table       string
+	column      string
+	target      string
+	ifNotExists bool
+	class       string
+	options     map[string]string
+}
+
+// Create the create index builder, the name can be empty to let
+// Cassandra generate it.
+func CreateIndex(name, table string) *CreateIndexBuilder {
+	return &CreateIndexBuilder{name: name, table: table}
+}
+
+// Index the column, or the values of the collection column.
+func (c *CreateIndexBuilder) On(column string) *CreateIndexBuilder {
+	c.column, c.target = column, ""
+	return c
+}
+
+// Index the values of the collection column, same as On.
+func (c *CreateIndexBuilder) OnValues(column string) *CreateIndexBuilder {
+	c.column, c.target = column, indexValues
+	return c
+}
+
+// Index the keys of the map column.
+func (c *CreateIndexBuilder) OnKeys(column string) *CreateIndexBuilder {
+	c.column, c.target = column, indexKeys
+	return c
+}
+
+// Index the entries(key and value) of the map column.
+func (c *CreateIndexBuilder) OnEntries(column string) *CreateIndexBuilder {
+	c.column, c.target = column, indexEntries
+	return c
+}
+
+// Index the whole frozen collection column.
+func (c *CreateIndexBuilder) OnFull(column string) *CreateIndexBuilder {
+	c.column, c.target = column, indexFull
+	return c
+}
+
+// Set if not exists
+func (c *CreateIndexBuilder) IfNotExists(e bool) *CreateIndexBuilder {
+	c.ifNotExists = e
+	return c
+}
+
+// Make it a custom index of the class, the options go to WITH OPTIONS.
+func (c *CreateIndexBuilder) Custom(class string, options map[string]string) *CreateIndexBuilder {
+	c.class = class
+	c.options = options
+	return c
+}
+
+// Make it a SASI index, the options are like {"mode": "CONTAINS"}.
+func (c *CreateIndexBuilder) SASI(options map[string]string) *CreateIndexBuilder {
+	return c.Custom(SASIIndexClass, options)
+}
+
+func (c *CreateIndexBuilder) schemaError(reason string) error {
+	return &SchemaError{Statement: "CREATE INDEX " + c.name, Reason: reason}
+}
+
+// Validate
+func (c *CreateIndexBuilder) Validate() error {
+	if len(c.table) == 0 {
+		return errEmptyTable
+	}
+
+	if len(c.column) == 0 {
+		return errEmptyColumn
+	}
+
+	if strings.Contains(c.name, ".") {
+		return c.schemaError("index name can't have keyspace, it's the keyspace of the table")
+	}
+
+	if len(c.class) == 0 && len(c.options) > 0 {
+		return c.schemaError("options need custom index")
+	}
+
+	if c.class == SASIIndexClass && len(c.target) > 0 {
+		return c.schemaError("SASI doesn't support collection index")
+	}
+
+	return nil
+}
+
+// Build the CREATE INDEX statement, it has no values.
+// Example:
+//
+//	CREATE CUSTOM INDEX IF NOT EXISTS users_name ON users (name) USING 'org.apache.cassandra.index.sasi.SASIIndex' WITH OPTIONS = {'mode': 'CONTAINS'}
+func (c *CreateIndexBuilder) ToQuery() (string, []interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	column, err := QuoteIdentifier(c.column)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(createIndex)
+	if len(c.class) > 0 {
+		buf.WriteString("CUSTOM ")
+	}
+	buf.WriteString("INDEX ")
+	if c.ifNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	if len(c.name) > 0 {
+		name, err := QuoteTable(c.name)
+		if err != nil {
+			return "", nil, err
+		}
+		buf.WriteString(name + space)
+	}
+
+	buf.WriteString("ON " + table + space + leftPar)
+	if len(c.target) > 0 {
+		buf.WriteString(c.target + leftPar + column + rightPar)
+	} else {
+		buf.WriteString(column)
+	}
+	buf.WriteString(rightPar)
+
+	if len(c.class) > 0 {
+		class, _ := optionLiteral(c.class)
+		buf.WriteString(" USING " + class)
+	}
+
+	if len(c.options) > 0 {
+		opts, err := optionLiteral(c.options)
+		if err != nil {
+			return "", nil, err
+		}
+		buf.WriteString(" WITH OPTIONS = " + opts)
+	}
+
+	return buf.String(), nil, nil
+}
+
+func (c *CreateIndexBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *CreateIndexBuilder) statementKind() StatementKind {
+	return KindSchema
+}
+
+// The type of drop index builder which wrap the DROP INDEX statement.
+type DropIndexBuilder struct {
+	name     string
+	ifExists bool
+}
+
+// Create the drop index builder, ks.index is supported.
+func DropIndex(name string) *DropIndexBuilder {
+	return &DropIndexBuilder{name: name}
+}
+
+// Set if exists
+func (c *DropIndexBuilder) IfExists(e bool) *DropIndexBuilder {
+	c.ifExists = e
+	return c
+}
+
+// Build the DROP INDEX statement, it has no values.
+func (c *DropIndexBuilder) ToQuery() (string, []interface{}, error) {
+	if len(c.name) == 0 {
+		return "", nil, &SchemaError{Statement: "DROP INDEX", Reason: "need index name"}
+	}
+
+	name, err := QuoteTable(c.name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	str := dropIndex
+	if c.ifExists {
+		str += ifExists
+	}
+	return str + name, nil, nil
+}
+
+func (c *DropIndexBuilder) statementTable() string {
+	return c.name
+}
+
+func (c *DropIndexBuilder) statementKind() StatementKind {
+	return KindSchema
+}
@@ -0,0 +1,50 @@
+package cqlbuilder
+
+import (
+	"testing"
+)
+
+func TestCreateIndex(t *testing.T) {
+	cases := []struct {
+		builder  CqlBuilder
+		expected string
+	}{
+		{CreateIndex("users_by_email", "users").On("email"), " CREATE INDEX users_by_email ON users (email)"},
+		{CreateIndex("", "ks.users").IfNotExists(true).OnKeys("attrs"), " CREATE INDEX IF NOT EXISTS ON ks.users (KEYS(attrs))"},
+		{CreateIndex("attrs_entries", "users").OnEntries("attrs"), " CREATE INDEX attrs_entries ON users (ENTRIES(attrs))"},
+		{CreateIndex("tags_full", "users").OnFull("tags"), " CREATE INDEX tags_full ON users (FULL(tags))"},
+		{CreateIndex("tags_values", "users").OnValues("tags"), " CREATE INDEX tags_values ON users (VALUES(tags))"},
+		{CreateIndex("users_name", "users").On("name").SASI(map[string]string{"mode": "CONTAINS", "analyzed": "true"}),
+			" CREATE CUSTOM INDEX users_name ON users (name) USING 'org.apache.cassandra.index.sasi.SASIIndex' WITH OPTIONS = {'analyzed': 'true', 'mode': 'CONTAINS'}"},
+		{CreateIndex("users_sai", "users").On("age").Custom("StorageAttachedIndex", nil), " CREATE CUSTOM INDEX users_sai ON users (age) USING 'StorageAttachedIndex'"},
+		{DropIndex("ks.users_by_email").IfExists(true), " DROP INDEX IF EXISTS ks.users_by_email"},
+		{DropIndex("users_by_email"), " DROP INDEX users_by_email"},
+	}
+
+	for _, c := range cases {
+		str, vals, err := c.builder.ToQuery()
+		if err != nil || str != c.expected || len(vals) != 0 {
+			t.Logf("str %s err %v, expected %s", str, err, c.expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestCreateIndexValidate(t *testing.T) {
+	cases := []CqlBuilder{
+		CreateIndex("idx", "users"),
+		CreateIndex("idx", "").On("a"),
+		CreateIndex("ks.idx", "users").On("a"),
+		CreateIndex("idx", "users").On("a").Custom("", map[string]string{"mode": "PREFIX"}),
+		CreateIndex("idx", "users").OnKeys("attrs").SASI(nil),
+		CreateIndex("bad name", "users").On("a"),
+		DropIndex(""),
+	}
+
+	for i, c := range cases {
+		if _, _, err := c.ToQuery(); err == nil {
+			t.Logf("case %d: err expected", i)
+			t.FailNow()
+		}
+	}
+}
@@ -11,6 +11,8 @@ type InsertBuilder struct {
 	table       string
 	ifNotExists bool
 	ttl         int
+	options     *QueryOptions
+	schema      *TableSchema
 }
 
 // Set a value
@@ -51,20 +53,38 @@ func (c *InsertBuilder) ToQuery() (string, []interface{}, error) {
 		return "", nil, err
 	}
 
+	if c.schema != nil {
+		if err := c.schema.validateInsert(c); err != nil {
+			return "", nil, err
+		}
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns := make([]string, len(c.colums))
+	for i, col := range c.colums {
+		if columns[i], err = quoteTarget(col); err != nil {
+			return "", nil, err
+		}
+	}
+
 	var buf, vals bytes.Buffer
 	values := make([]interface{}, 0, len(c.values))
 
 	buf.WriteString(insert)
-	buf.WriteString(c.table)
+	buf.WriteString(table)
 	buf.WriteString(leftPar)
 	vals.WriteString(leftPar)
 
-	for i := 0; i < len(c.colums); i++ {
+	for i := 0; i < len(columns); i++ {
 		if i > 0 {
 			buf.WriteString(comma)
 			vals.WriteString(comma)
 		}
-		buf.WriteString(c.colums[i])
+		buf.WriteString(columns[i])
 		vals.WriteString(questMak)
 	}
 
@@ -88,3 +108,28 @@ func (c *InsertBuilder) ToQuery() (string, []interface{}, error) {
 
 	return buf.String(), values, nil
 }
+
+// Attach the query options(consistency, page size ...) to the statement.
+func (c *InsertBuilder) SetOptions(o *QueryOptions) *InsertBuilder {
+	c.options = o
+	return c
+}
+
+// Bind the statement to the table schema, ToQuery validates the columns,
+// keys and value types by it.
+func (c *InsertBuilder) SetSchema(s *TableSchema) *InsertBuilder {
+	c.schema = s
+	return c
+}
+
+func (c *InsertBuilder) queryOptions() *QueryOptions {
+	return c.options
+}
+
+func (c *InsertBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *InsertBuilder) statementKind() StatementKind {
+	return KindInsert
+}
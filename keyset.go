@@ -0,0 +1,93 @@
+package cqlbuilder
+
+import (
+	"errors"
+)
+
+var (
+	errKeysetValues  = errors.New("cqlbuilder: keyset values must match the clustering columns")
+	errKeysetColumns = errors.New("cqlbuilder: keyset columns must be the clustering columns of the table")
+	errKeysetMixed   = errors.New("cqlbuilder: keyset can't page the mixed clustering orders")
+	errKeysetOrderBy = errors.New("cqlbuilder: keyset select already has ORDER BY")
+)
+
+// The clustering columns for the keyset pagination, in the order of the
+// primary key. Desc is the CLUSTERING ORDER of the table, all the columns
+// must share the same order.
+type Keyset struct {
+	Columns []string
+	Desc    bool
+	// The optional CLUSTERING ORDER of every column, KeysetPage fails if
+	// they are mixed: the tuple comparison doesn't follow such order.
+	Orders []bool
+}
+
+// Return the copy of the select with the keyset pagination: the tuple
+// comparison of the clustering columns against the last row's values,
+// ORDER BY and LIMIT. The select of the caller is not changed, so it can be
+// reused for every page.
+// last is the clustering values of the last row of the previous page(or the
+// first row when backward), nil for the first page; it must have the value
+// of every column, a prefix would skip the rest rows of that prefix.
+// Forward pages follow the clustering order of the table, backward pages
+// are in the reverse order so the caller need reverse them.
+// Example:
+//
+//	// (day,seq)>(?,?) ORDER BY day ASC,seq ASC LIMIT 50
+//	page := sel.KeysetPage(Keyset{Columns: []string{"day", "seq"}}, []interface{}{lastDay, lastSeq}, false, 50)
+func (c *SelectBuilder) KeysetPage(k Keyset, last []interface{}, backward bool, pageSize int) *SelectBuilder {
+	ret := c.clone()
+	if err := k.validate(ret, last); err != nil {
+		ret.err = err
+		return ret
+	}
+
+	desc := k.Desc
+	if len(k.Orders) > 0 {
+		desc = k.Orders[0]
+	}
+	// The order of this page, the table order reversed when backward.
+	desc = desc != backward
+
+	if len(last) > 0 {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		ret.Where(&cmpBuilder{columns: k.Columns, op: op, values: last})
+	}
+
+	for _, col := range k.Columns {
+		ret.OrderBy(col, desc)
+	}
+
+	return ret.SetLimit(pageSize)
+}
+
+func (k *Keyset) validate(sel *SelectBuilder, last []interface{}) error {
+	if len(last) > 0 && len(last) != len(k.Columns) {
+		return errKeysetValues
+	}
+
+	if len(sel.orderColumns) > 0 {
+		return errKeysetOrderBy
+	}
+
+	for _, desc := range k.Orders {
+		if desc != k.Orders[0] {
+			return errKeysetMixed
+		}
+	}
+
+	if sel.schema != nil && !sel.schema.missing {
+		if len(k.Columns) != len(sel.schema.ClusteringKeys) {
+			return errKeysetColumns
+		}
+		for i, col := range k.Columns {
+			if canonicalName(col) != sel.schema.ClusteringKeys[i] {
+				return errKeysetColumns
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package cqlbuilder
+
+import (
+	"testing"
+)
+
+func TestKeysetPage(t *testing.T) {
+	ks := Keyset{Columns: []string{"day", "seq"}}
+	cases := []struct {
+		keyset   Keyset
+		last     []interface{}
+		backward bool
+		expected string
+		values   int
+	}{
+		{ks, nil, false, " SELECT v FROM t WHERE id=? ORDER BY day ASC,seq ASC LIMIT 10", 1},
+		{ks, []interface{}{20200101, 5}, false, " SELECT v FROM t WHERE id=? AND (day,seq)>(?,?) ORDER BY day ASC,seq ASC LIMIT 10", 3},
+		{ks, []interface{}{20200101, 5}, true, " SELECT v FROM t WHERE id=? AND (day,seq)<(?,?) ORDER BY day DESC,seq DESC LIMIT 10", 3},
+		{Keyset{Columns: []string{"day", "seq"}, Desc: true}, []interface{}{20200101, 5}, false, " SELECT v FROM t WHERE id=? AND (day,seq)<(?,?) ORDER BY day DESC,seq DESC LIMIT 10", 3},
+		{Keyset{Columns: []string{"day", "seq"}, Desc: true}, []interface{}{20200101, 5}, true, " SELECT v FROM t WHERE id=? AND (day,seq)>(?,?) ORDER BY day ASC,seq ASC LIMIT 10", 3},
+	}
+
+	for _, c := range cases {
+		str, vals, err := Select("t").AddColumn("v").Where(Eq("id", 1)).KeysetPage(c.keyset, c.last, c.backward, 10).ToQuery()
+		if err != nil || str != c.expected || len(vals) != c.values {
+			t.Logf("str %s vals %v err %v, expected %s", str, vals, err, c.expected)
+			t.FailNow()
+		}
+	}
+
+	sel := Select("t").AddColumn("v").Where(Eq("id", 1))
+	errCases := []struct {
+		sel    *SelectBuilder
+		keyset Keyset
+		last   []interface{}
+		err    error
+	}{
+		{sel, ks, []interface{}{1, 2, 3}, errKeysetValues},
+		// The prefix would skip the rest rows of the day.
+		{sel, ks, []interface{}{20200101}, errKeysetValues},
+		{sel, Keyset{Columns: []string{"day", "seq"}, Orders: []bool{false, true}}, nil, errKeysetMixed},
+		{sel.KeysetPage(ks, nil, false, 10), ks, nil, errKeysetOrderBy},
+		{Select("t").AddColumn("v").SetSchema(&TableSchema{Name: "t", PartitionKeys: []string{"id"}, ClusteringKeys: []string{"day", "seq", "n"}}), ks, nil, errKeysetColumns},
+	}
+	for _, c := range errCases {
+		if _, _, err := c.sel.KeysetPage(c.keyset, c.last, false, 10).ToQuery(); err != c.err {
+			t.Logf("err %v expected %v", err, c.err)
+			t.FailNow()
+		}
+	}
+
+	// The select of the caller is reused for every page.
+	sel.KeysetPage(ks, []interface{}{20200101, 5}, false, 10)
+	if str, vals, err := sel.ToQuery(); err != nil || str != " SELECT v FROM t WHERE id=?" || len(vals) != 1 {
+		t.Logf("select changed %s %v %v", str, vals, err)
+		t.FailNow()
+	}
+}
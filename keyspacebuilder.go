@@ -0,0 +1,196 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	createKeyspace = " CREATE KEYSPACE "
+	alterKeyspace  = " ALTER KEYSPACE "
+	dropKeyspace   = " DROP KEYSPACE "
+)
+
+// The type of keyspace builder which wrap the CREATE/ALTER KEYSPACE statement.
+// Example:
+//
+//	CreateKeyspace("app").IfNotExists(true).SimpleStrategy(1)
+//	AlterKeyspace("app").NetworkTopologyStrategy(map[string]int{"dc1": 3, "dc2": 2})
+type KeyspaceBuilder struct {
+	keyspace    string
+	alter       bool
+	ifNotExists bool
+	options     tableOptions
+}
+
+// Create the create keyspace builder.
+func CreateKeyspace(ks string) *KeyspaceBuilder {
+	return &KeyspaceBuilder{keyspace: ks}
+}
+
+// Create the alter keyspace builder.
+func AlterKeyspace(ks string) *KeyspaceBuilder {
+	return &KeyspaceBuilder{keyspace: ks, alter: true}
+}
+
+// Set if not exists, only for CREATE KEYSPACE.
+func (c *KeyspaceBuilder) IfNotExists(e bool) *KeyspaceBuilder {
+	c.ifNotExists = e
+	return c
+}
+
+// Set the replication of SimpleStrategy.
+func (c *KeyspaceBuilder) SimpleStrategy(replicationFactor int) *KeyspaceBuilder {
+	c.options.set("replication", map[string]interface{}{
+		"class":              "SimpleStrategy",
+		"replication_factor": replicationFactor,
+	})
+	return c
+}
+
+// Set the replication of NetworkTopologyStrategy, the map is dc => replication factor.
+func (c *KeyspaceBuilder) NetworkTopologyStrategy(dcs map[string]int) *KeyspaceBuilder {
+	m := map[string]interface{}{"class": "NetworkTopologyStrategy"}
+	for dc, rf := range dcs {
+		m[dc] = rf
+	}
+	c.options.set("replication", m)
+	return c
+}
+
+// Set durable_writes.
+func (c *KeyspaceBuilder) DurableWrites(d bool) *KeyspaceBuilder {
+	c.options.set("durable_writes", d)
+	return c
+}
+
+func (c *KeyspaceBuilder) statement() string {
+	if c.alter {
+		return "ALTER KEYSPACE " + c.keyspace
+	}
+	return "CREATE KEYSPACE " + c.keyspace
+}
+
+// Validate
+func (c *KeyspaceBuilder) Validate() error {
+	if len(c.keyspace) == 0 {
+		return &SchemaError{Statement: c.statement(), Reason: "need keyspace name"}
+	}
+
+	if strings.Contains(c.keyspace, ".") {
+		return &IdentifierError{Name: c.keyspace, Reason: "keyspace name can't have dot"}
+	}
+
+	if c.alter && c.ifNotExists {
+		return &SchemaError{Statement: c.statement(), Reason: "IF NOT EXISTS is only for CREATE"}
+	}
+
+	replication, ok := c.options.values["replication"].(map[string]interface{})
+	if !ok {
+		if !c.alter || c.options.empty() {
+			return &SchemaError{Statement: c.statement(), Reason: "need replication"}
+		}
+		return nil
+	}
+
+	if len(replication) < 2 {
+		return &SchemaError{Statement: c.statement(), Reason: "need replication factor"}
+	}
+	for k, v := range replication {
+		rf, ok := v.(int)
+		if !ok {
+			continue
+		}
+		if rf < 0 || k == "replication_factor" && rf == 0 {
+			return &SchemaError{Statement: c.statement(), Reason: "invalid replication factor of " + k}
+		}
+	}
+
+	return nil
+}
+
+// Build the CREATE/ALTER KEYSPACE statement, it has no values.
+// Example:
+//
+//	CREATE KEYSPACE IF NOT EXISTS app WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1} AND durable_writes = true
+func (c *KeyspaceBuilder) ToQuery() (string, []interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	ks, err := QuoteTable(c.keyspace)
+	if err != nil {
+		return "", nil, err
+	}
+
+	opts, err := c.options.render()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	if c.alter {
+		buf.WriteString(alterKeyspace)
+	} else {
+		buf.WriteString(createKeyspace)
+	}
+	if c.ifNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	buf.WriteString(ks)
+	buf.WriteString(with)
+	buf.WriteString(opts)
+
+	return buf.String(), nil, nil
+}
+
+func (c *KeyspaceBuilder) statementTable() string {
+	return c.keyspace
+}
+
+func (c *KeyspaceBuilder) statementKind() StatementKind {
+	return KindSchema
+}
+
+// The type of drop keyspace builder which wrap the DROP KEYSPACE statement.
+type DropKeyspaceBuilder struct {
+	keyspace string
+	ifExists bool
+}
+
+// Create the drop keyspace builder.
+func DropKeyspace(ks string) *DropKeyspaceBuilder {
+	return &DropKeyspaceBuilder{keyspace: ks}
+}
+
+// Set if exists
+func (c *DropKeyspaceBuilder) IfExists(e bool) *DropKeyspaceBuilder {
+	c.ifExists = e
+	return c
+}
+
+// Build the DROP KEYSPACE statement, it has no values.
+func (c *DropKeyspaceBuilder) ToQuery() (string, []interface{}, error) {
+	if len(c.keyspace) == 0 || strings.Contains(c.keyspace, ".") {
+		return "", nil, &IdentifierError{Name: c.keyspace, Reason: "invalid keyspace name"}
+	}
+
+	ks, err := QuoteTable(c.keyspace)
+	if err != nil {
+		return "", nil, err
+	}
+
+	str := dropKeyspace
+	if c.ifExists {
+		str += ifExists
+	}
+	return str + ks, nil, nil
+}
+
+func (c *DropKeyspaceBuilder) statementTable() string {
+	return c.keyspace
+}
+
+func (c *DropKeyspaceBuilder) statementKind() StatementKind {
+	return KindSchema
+}
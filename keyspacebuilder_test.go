@@ -0,0 +1,73 @@
+package cqlbuilder
+
+import (
+	"testing"
+)
+
+func TestKeyspaceTypeViewDDL(t *testing.T) {
+	sel := Select("ks.users").AddColumns("id", "email", "name").Where(IsNotNull("email"))
+	cases := []struct {
+		builder  CqlBuilder
+		expected string
+	}{
+		{CreateKeyspace("app").IfNotExists(true).SimpleStrategy(1).DurableWrites(true),
+			" CREATE KEYSPACE IF NOT EXISTS app WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1} AND durable_writes = true"},
+		{CreateKeyspace("app").NetworkTopologyStrategy(map[string]int{"dc2": 2, "DC1": 3}),
+			" CREATE KEYSPACE app WITH replication = {'class': 'NetworkTopologyStrategy', 'DC1': 3, 'dc2': 2}"},
+		{AlterKeyspace("app").DurableWrites(false), " ALTER KEYSPACE app WITH durable_writes = false"},
+		{DropKeyspace("app").IfExists(true), " DROP KEYSPACE IF EXISTS app"},
+
+		{CreateType("ks.address").IfNotExists(true).Field("street", Text).Field("zip", Int).Field("phones", ListOf(Text)).Field("geo", Frozen(UDT("point"))),
+			" CREATE TYPE IF NOT EXISTS ks.address (street text,zip int,phones list<text>,geo frozen<point>)"},
+		{AlterType("address").AddField("city", Text), " ALTER TYPE address ADD city text"},
+		{AlterType("address").RenameField("zip", "zip_code").RenameField("street", "line1"), " ALTER TYPE address RENAME zip TO zip_code AND street TO line1"},
+		{DropType("ks.address"), " DROP TYPE ks.address"},
+
+		{CreateMaterializedView("ks.users_by_email", sel).IfNotExists(true).PartitionKey("email").ClusteringKey("id").ClusteringOrder("id", true).With("comment", "by email"),
+			" CREATE MATERIALIZED VIEW IF NOT EXISTS ks.users_by_email AS SELECT id,email,name FROM ks.users WHERE email IS NOT NULL AND id IS NOT NULL PRIMARY KEY (email,id) WITH CLUSTERING ORDER BY (id DESC) AND comment = 'by email'"},
+		{CreateMaterializedView("v", Select("t")).PartitionKey("a", "b").ClusteringKey("c"),
+			" CREATE MATERIALIZED VIEW v AS SELECT * FROM t WHERE a IS NOT NULL AND b IS NOT NULL AND c IS NOT NULL PRIMARY KEY ((a,b),c)"},
+		{DropMaterializedView("ks.users_by_email").IfExists(true), " DROP MATERIALIZED VIEW IF EXISTS ks.users_by_email"},
+	}
+
+	for _, c := range cases {
+		str, vals, err := c.builder.ToQuery()
+		if err != nil || str != c.expected || len(vals) != 0 {
+			t.Logf("str %s err %v, expected %s", str, err, c.expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestKeyspaceTypeViewValidate(t *testing.T) {
+	cases := []CqlBuilder{
+		CreateKeyspace("app"),
+		CreateKeyspace("ks.app").SimpleStrategy(1),
+		CreateKeyspace("app").SimpleStrategy(0),
+		CreateKeyspace("app").NetworkTopologyStrategy(nil),
+		AlterKeyspace("app"),
+		AlterKeyspace("app").IfNotExists(true).DurableWrites(true),
+		DropKeyspace("a.b"),
+
+		CreateType("address"),
+		CreateType("address").Field("a", Int).Field("a", Text),
+		CreateType("address").Field("geo", UDT("point")),
+		CreateType("address").Field("n", Counter),
+		AlterType("address"),
+		AlterType("address").AddField("a", Int).AddField("b", Int),
+		AlterType("address").AddField("a", Int).RenameField("b", "c"),
+
+		CreateMaterializedView("v", Select("t").AddColumn("a")),
+		CreateMaterializedView("v", Select("t").AddColumn("a")).PartitionKey("b"),
+		CreateMaterializedView("v", Select("t").AddColumn("a").Where(Eq("a", 1))).PartitionKey("a"),
+		CreateMaterializedView("v", nil).PartitionKey("a"),
+		CreateMaterializedView("v", Select("t")).PartitionKey("a").ClusteringKey("b").ClusteringOrder("c", true),
+	}
+
+	for i, c := range cases {
+		if _, _, err := c.ToQuery(); err == nil {
+			t.Logf("case %d: err expected", i)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package cqlbuilder
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The rules of the linter.
+const (
+	RuleInvalid             = "invalid-statement"
+	RuleAllowFiltering      = "allow-filtering"
+	RulePartitionKeyIn      = "partition-key-in"
+	RuleUnboundedSelect     = "unbounded-select"
+	RuleMultiPartitionBatch = "multi-partition-batch"
+	RuleMixedLWTBatch       = "mixed-lwt-batch"
+	RuleMissingTTL          = "missing-ttl"
+)
+
+// The default max number of values of IN on the partition key.
+const DefaultMaxInValues = 10
+
+// The severity of the finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// The risky thing found in the statement.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// The name of the registered query, empty if not registered.
+	Query     string `json:"query,omitempty"`
+	Table     string `json:"table"`
+	Statement string `json:"statement"`
+	Message   string `json:"message"`
+}
+
+func (f Finding) String() string {
+	name := f.Table
+	if len(f.Query) > 0 {
+		name = f.Query + " " + name
+	}
+	return fmt.Sprintf("%s %s %s: %s\n\t%s", f.Severity, f.Rule, name, f.Message, f.Statement)
+}
+
+// The linter checks the statements built by the builders before they ship.
+// The primary keys come from the schema bound to the builder or the
+// Schemas, the checks need them(unbounded-select, partition-key-in) are
+// skipped if the schema is unknown.
+// Example:
+//
+//	l := &Linter{Schemas: reg, ExpiringTables: []string{"sessions"}}
+//	for _, f := range l.Lint(Select("users").AddColumn("name").Where(Eq("email", e)).SetAllowFiltering(true)) {
+//		log.Println(f)
+//	}
+type Linter struct {
+	Schemas *SchemaRegistry
+	// The tables whose rows must expire, the INSERT/UPDATE without TTL is
+	// reported. ks.table is supported.
+	ExpiringTables []string
+	// The IN on the partition key with more values is reported, default is
+	// DefaultMaxInValues.
+	MaxInValues int
+}
+
+// Lint the statement with the default linter.
+func Lint(c CqlBuilder) []Finding {
+	return (&Linter{}).Lint(c)
+}
+
+// Lint the batch with the default linter.
+func LintBatch(b *BatchBuilder) []Finding {
+	return (&Linter{}).LintBatch(b)
+}
+
+func (l *Linter) maxInValues() int {
+	if l.MaxInValues <= 0 {
+		return DefaultMaxInValues
+	}
+	return l.MaxInValues
+}
+
+// The schema bound to the builder, or the registered one.
+func (l *Linter) schemaOf(c CqlBuilder, table string) *TableSchema {
+	return schemaOfBuilder(c, l.Schemas, table)
+}
+
+func (l *Linter) isExpiring(table string) bool {
+	name := table[strings.LastIndex(table, ".")+1:]
+	for _, t := range l.ExpiringTables {
+		if t == table || !strings.Contains(t, ".") && t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint the statement, nil if nothing found.
+func (l *Linter) Lint(c CqlBuilder) []Finding {
+	str, _, err := c.ToQuery()
+	table, _ := describeStatement(c)
+	str = strings.TrimSpace(str)
+
+	var findings []Finding
+	report := func(rule string, sev Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{Rule: rule, Severity: sev, Table: table, Statement: str, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if err != nil {
+		report(RuleInvalid, SeverityError, "%v", err)
+		return findings
+	}
+
+	schema := l.schemaOf(c, table)
+	var conds []conditionBuilder
+	ttl := -1
+	switch b := c.(type) {
+	case *SelectBuilder:
+		conds = b.whereConditions
+		if b.allowFiltering {
+			report(RuleAllowFiltering, SeverityWarning, "ALLOW FILTERING scans all the rows not restricted by the primary key")
+		}
+		if schema != nil && b.limitNumber <= 0 && !restrictsRow(schema, conds) {
+			report(RuleUnboundedSelect, SeverityWarning, "SELECT without LIMIT may return unbounded rows")
+		}
+	case *InsertBuilder:
+		ttl = b.ttl
+	case *UpdateBuilder:
+		conds = b.whereConditions
+		ttl = b.ttl
+	case *DeleteBuilder:
+		conds = b.whereConditions
+	}
+
+	for _, cond := range conds {
+		in, ok := cond.(*inBuilder)
+		if !ok {
+			continue
+		}
+		// IN on the clustering key hits one partition.
+		if schema == nil || !contains(schema.PartitionKeys, canonicalName(in.column)) {
+			continue
+		}
+		if n := len(toSlice(in.values)); n > l.maxInValues() {
+			report(RulePartitionKeyIn, SeverityWarning, "IN on partition key %s with %d values fans out to %d partitions from one coordinator", in.column, n, n)
+		}
+	}
+
+	if ttl == 0 && l.isExpiring(table) {
+		report(RuleMissingTTL, SeverityWarning, "table is declared as expiring but no TTL is set")
+	}
+
+	return findings
+}
+
+// Whether the full primary key is restricted by = or IN, the select returns
+// one row for each key.
+func restrictsRow(s *TableSchema, conds []conditionBuilder) bool {
+	restricted := map[string]bool{}
+	for _, cond := range conds {
+		switch c := cond.(type) {
+		case *eqBuilder:
+			restricted[canonicalName(c.column)] = true
+		case *inBuilder:
+			restricted[canonicalName(c.column)] = true
+		}
+	}
+	for _, k := range append(append([]string(nil), s.PartitionKeys...), s.ClusteringKeys...) {
+		if !restricted[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the statement is the lightweight transaction.
+func isLWT(c CqlBuilder) bool {
+	switch b := c.(type) {
+	case *InsertBuilder:
+		return b.ifNotExists
+	case *UpdateBuilder:
+		return len(b.ifConditions) > 0
+	case *DeleteBuilder:
+		return len(b.ifConditions) > 0
+	}
+	return false
+}
+
+// The partition of the statement, the values of the partition key. It's the
+// table if the schema is unknown, so only the batch of different tables is
+// known as multi-partition.
+func (l *Linter) partitionOf(c CqlBuilder) string {
+	table, _ := describeStatement(c)
+	schema := l.schemaOf(c, table)
+	if schema == nil {
+		return table
+	}
+
+	values := map[string]interface{}{}
+	switch b := c.(type) {
+	case *InsertBuilder:
+		for i, col := range b.colums {
+			values[canonicalName(col)] = b.values[i]
+		}
+	case *UpdateBuilder:
+		collectEq(values, b.whereConditions)
+	case *DeleteBuilder:
+		collectEq(values, b.whereConditions)
+	}
+
+	key := make([]interface{}, len(schema.PartitionKeys))
+	for i, k := range schema.PartitionKeys {
+		key[i] = values[k]
+	}
+	return fmt.Sprintf("%s%#v", schema.qualifiedName(), key)
+}
+
+func collectEq(values map[string]interface{}, conds []conditionBuilder) {
+	for _, cond := range conds {
+		if eq, ok := cond.(*eqBuilder); ok {
+			values[canonicalName(eq.column)] = eq.value
+		}
+	}
+}
+
+// Lint the batch and its statements. The batch is logged, the one spanning
+// partitions costs the batch log write.
+func (l *Linter) LintBatch(b *BatchBuilder) []Finding {
+	var findings []Finding
+	stmts := make([]string, 0, len(b.builders))
+	partitions := map[string]bool{}
+	lwt := 0
+	for _, c := range b.builders {
+		findings = append(findings, l.Lint(c)...)
+
+		str, _, _ := c.ToQuery()
+		stmts = append(stmts, strings.TrimSpace(str))
+		partitions[l.partitionOf(c)] = true
+		if isLWT(c) {
+			lwt++
+		}
+	}
+
+	table, _ := describeStatement(b)
+	report := func(rule string, sev Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{Rule: rule, Severity: sev, Table: table,
+			Statement: strings.Join(stmts, "; "), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if lwt > 0 && lwt < len(b.builders) {
+		report(RuleMixedLWTBatch, SeverityWarning, "the statements without IF are applied only if all the conditions hold")
+	}
+	if len(partitions) > 1 {
+		if lwt > 0 {
+			report(RuleMultiPartitionBatch, SeverityError, "conditional batch can't span %d partitions", len(partitions))
+		} else {
+			report(RuleMultiPartitionBatch, SeverityWarning, "logged batch spans %d partitions", len(partitions))
+		}
+	}
+
+	return findings
+}
+
+// The query registered for the lint, either the statement or the batch.
+type lintEntry struct {
+	name  string
+	stmt  CqlBuilder
+	batch *BatchBuilder
+}
+
+var lintQueries struct {
+	sync.Mutex
+	entries []lintEntry
+}
+
+// Register the query to be linted by LintRegistered/LintCommand, usually
+// called in init of the package building the queries. The values are
+// placeholders, only the shape matters.
+// It's not the StatementRegistry: that one only sees the CQL text of the
+// statements executed at runtime, the lint runs before anything executes
+// and needs the builders for the conditions and the bound schema.
+func RegisterQuery(name string, c CqlBuilder) {
+	lintQueries.Lock()
+	defer lintQueries.Unlock()
+	lintQueries.entries = append(lintQueries.entries, lintEntry{name: name, stmt: c})
+}
+
+// Register the batch to be linted, see RegisterQuery.
+func RegisterBatch(name string, b *BatchBuilder) {
+	lintQueries.Lock()
+	defer lintQueries.Unlock()
+	lintQueries.entries = append(lintQueries.entries, lintEntry{name: name, batch: b})
+}
+
+// Lint the registered queries, in the order of name.
+func (l *Linter) LintRegistered() []Finding {
+	lintQueries.Lock()
+	entries := append([]lintEntry(nil), lintQueries.entries...)
+	lintQueries.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	var findings []Finding
+	for _, e := range entries {
+		var fs []Finding
+		if e.batch != nil {
+			fs = l.LintBatch(e.batch)
+		} else {
+			fs = l.Lint(e.stmt)
+		}
+		for i := range fs {
+			fs[i].Query = e.name
+		}
+		findings = append(findings, fs...)
+	}
+	return findings
+}
+
+// The CLI of the linter over the registered queries, return the exit code:
+// 0 if nothing reported, 1 if any finding at or above -fail, 2 for the bad
+// usage. It's the main of the binary importing the packages which register
+// the queries, the cqlbuilder-lint command builds and runs such binary:
+//
+//	cqlbuilder-lint myapp/queries -- -schema schema.cql -json
+//
+// or write the main by hand:
+//
+//	package main
+//
+//	import (
+//		"os"
+//
+//		cb "cqlbuilder"
+//		_ "myapp/queries"
+//	)
+//
+//	func main() {
+//		os.Exit(cb.LintCommand(os.Args[1:], os.Stdout))
+//	}
+//
+// and run it with the flags:
+//
+//	lint -schema schema.cql -expiring sessions,tokens -max-in 20 -json
+func LintCommand(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(out)
+	var (
+		schemas  = fs.String("schema", "", "the comma separated CQL scripts of the tables")
+		expiring = fs.String("expiring", "", "the comma separated tables whose rows must expire")
+		maxIn    = fs.Int("max-in", DefaultMaxInValues, "the max number of values of IN on the partition key")
+		asJSON   = fs.Bool("json", false, "print the findings as JSON")
+		fail     = fs.String("fail", string(SeverityWarning), "the lowest severity failing the lint, error or warning")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *fail != string(SeverityWarning) && *fail != string(SeverityError) {
+		fmt.Fprintf(out, "lint: unknown severity %q\n", *fail)
+		return 2
+	}
+
+	l := &Linter{Schemas: NewSchemaRegistry(), MaxInValues: *maxIn}
+	for _, f := range splitList(*schemas) {
+		ks, err := LoadSchemaFile(f)
+		if err != nil {
+			fmt.Fprintf(out, "lint: %s: %v\n", f, err)
+			return 2
+		}
+		l.Schemas.Register(ks.Tables...)
+	}
+	l.ExpiringTables = splitList(*expiring)
+
+	findings := l.LintRegistered()
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if findings == nil {
+			findings = []Finding{}
+		}
+		enc.Encode(findings)
+	} else {
+		for _, f := range findings {
+			fmt.Fprintln(out, f)
+		}
+	}
+
+	for _, f := range findings {
+		if f.Severity == SeverityError || *fail == string(SeverityWarning) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func splitList(s string) []string {
+	var ret []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
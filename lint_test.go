@@ -0,0 +1,137 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func lintSchemas() *SchemaRegistry {
+	return NewSchemaRegistry().Register(SchemaOf(CreateTable("app.users").
+		Column("org_id", Text).Column("id", Int).Column("name", Text).
+		PartitionKey("org_id").ClusteringKey("id")))
+}
+
+func rulesOf(findings []Finding) []string {
+	rules := make([]string, len(findings))
+	for i, f := range findings {
+		rules[i] = f.Rule + "/" + string(f.Severity)
+	}
+	return rules
+}
+
+func expectRules(t *testing.T, findings []Finding, expected ...string) {
+	rules := rulesOf(findings)
+	if len(rules) != len(expected) {
+		t.Logf("findings %v, expected %v", findings, expected)
+		t.FailNow()
+	}
+	for i := range rules {
+		if rules[i] != expected[i] {
+			t.Logf("findings %v, expected %v", findings, expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestLintSelect(t *testing.T) {
+	l := &Linter{Schemas: lintSchemas(), MaxInValues: 2}
+
+	// one row by the full primary key
+	expectRules(t, l.Lint(Select("app.users").AddColumn("name").Where(Eq("org_id", "o1")).Where(Eq("id", 1))))
+
+	expectRules(t, l.Lint(Select("app.users").AddColumn("name").Where(Eq("org_id", "o1")).SetLimit(10)))
+
+	expectRules(t, l.Lint(Select("users").AddColumn("name").Where(Eq("name", "n")).SetAllowFiltering(true)),
+		RuleAllowFiltering+"/warning", RuleUnboundedSelect+"/warning")
+
+	expectRules(t, l.Lint(Select("users").AddColumn("name").Where(In("org_id", []string{"a", "b", "c"})).Where(In("id", []int{1, 2, 3}))),
+		RulePartitionKeyIn+"/warning")
+
+	// the schema is unknown, the checks need the primary key are skipped
+	expectRules(t, Lint(Select("other").AddColumn("name").Where(In("id", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}))))
+	expectRules(t, Lint(Select("other").AddColumn("name").Where(Eq("name", "n")).SetAllowFiltering(true)),
+		RuleAllowFiltering+"/warning")
+
+	f := Lint(Select("").AddColumn("name"))
+	expectRules(t, f, RuleInvalid+"/error")
+}
+
+func TestLintTTL(t *testing.T) {
+	l := &Linter{ExpiringTables: []string{"sessions", "app.tokens"}}
+
+	expectRules(t, l.Lint(Insert("app.sessions").SetValue("id", 1)), RuleMissingTTL+"/warning")
+	expectRules(t, l.Lint(Insert("app.sessions").SetValue("id", 1).SetTtl(60)))
+	expectRules(t, l.Lint(Update("app.tokens").SetValue("v", 1).Where(Eq("id", 1))), RuleMissingTTL+"/warning")
+	expectRules(t, l.Lint(Update("other.tokens").SetValue("v", 1).Where(Eq("id", 1))))
+	expectRules(t, l.Lint(Delete("sessions").Where(Eq("id", 1))))
+}
+
+func TestLintBatch(t *testing.T) {
+	l := &Linter{Schemas: lintSchemas()}
+
+	// the same partition
+	b := StartBatch().
+		Add(Insert("app.users").SetValue("org_id", "o1").SetValue("id", 1)).
+		Add(Update("users").SetValue("name", "n").Where(Eq("org_id", "o1")).Where(Eq("id", 2)))
+	expectRules(t, l.LintBatch(b))
+
+	b.Add(Delete("users").Where(Eq("org_id", "o2")).Where(Eq("id", 1)))
+	expectRules(t, l.LintBatch(b), RuleMultiPartitionBatch+"/warning")
+
+	b.Add(Update("users").SetValue("name", "n").Where(Eq("org_id", "o1")).Where(Eq("id", 1)).If(Exists()))
+	expectRules(t, l.LintBatch(b), RuleMixedLWTBatch+"/warning", RuleMultiPartitionBatch+"/error")
+
+	// the tables without schema are different partitions
+	b = StartBatch().
+		Add(Insert("a").SetValue("id", 1)).
+		Add(Insert("b").SetValue("id", 1))
+	findings := LintBatch(b)
+	expectRules(t, findings, RuleMultiPartitionBatch+"/warning")
+	if findings[0].Table != "a,b" {
+		t.Logf("finding %v", findings[0])
+		t.FailNow()
+	}
+}
+
+func TestLintCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	schema := filepath.Join(dir, "schema.cql")
+	ioutil.WriteFile(schema, []byte("CREATE TABLE app.users (org_id text, id int, name text, PRIMARY KEY (org_id, id));"), 0644)
+
+	RegisterQuery("users.get", Select("app.users").AddColumn("name").Where(Eq("org_id", "o")).Where(Eq("id", 1)))
+	RegisterQuery("users.all", Select("app.users").AddColumn("name").Where(Eq("org_id", "o")))
+
+	var out bytes.Buffer
+	if code := LintCommand([]string{"-schema", schema, "-json"}, &out); code != 1 {
+		t.Logf("code %d output %s", code, out.String())
+		t.FailNow()
+	}
+
+	var findings []Finding
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil || len(findings) != 1 ||
+		findings[0].Query != "users.all" || findings[0].Rule != RuleUnboundedSelect {
+		t.Logf("findings %v err %v", findings, err)
+		t.FailNow()
+	}
+
+	out.Reset()
+	if code := LintCommand([]string{"-schema", schema, "-fail", "error"}, &out); code != 0 || out.Len() == 0 {
+		t.Logf("code %d output %s", code, out.String())
+		t.FailNow()
+	}
+
+	if code := LintCommand([]string{"-fail", "info"}, &out); code != 2 {
+		t.Logf("code %d", code)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,401 @@
+package cqlbuilder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	cql "github.com/gocql/gocql"
+)
+
+// The category of exec error reported to the metrics.
+type ErrorCategory string
+
+const (
+	ErrCategoryTimeout     ErrorCategory = "timeout"
+	ErrCategoryUnavailable ErrorCategory = "unavailable"
+	ErrCategoryNoHosts     ErrorCategory = "no_hosts"
+	ErrCategoryCanceled    ErrorCategory = "canceled"
+	ErrCategoryNotFound    ErrorCategory = "not_found"
+	ErrCategoryInvalid     ErrorCategory = "invalid"
+	ErrCategoryOther       ErrorCategory = "other"
+)
+
+// The key of the metrics.
+type MetricKey struct {
+	Table string
+	Kind  StatementKind
+}
+
+// The metrics interface used by InstrumentedExecManager, it can be backed
+// by prometheus/statsd etc.
+type ExecMetrics interface {
+	// Count one executed statement.
+	IncCount(key MetricKey)
+	// Record the latency of the statement.
+	ObserveLatency(key MetricKey, d time.Duration)
+	// Count the result of a CAS statement.
+	IncCAS(key MetricKey, applied bool)
+	// Count a failed statement.
+	IncError(key MetricKey, category ErrorCategory)
+}
+
+// Classify the error returned by the exec call, the wrapped errors are
+// classified by what they wrap.
+func CategorizeError(err error) ErrorCategory {
+	switch {
+	case errors.Is(err, errEmptyColumn), errors.Is(err, errEmptyTable), errors.Is(err, errEmptyCondition):
+		return ErrCategoryInvalid
+	case errors.Is(err, cql.ErrTimeoutNoResponse), errors.Is(err, cql.ErrTooManyTimeouts), errors.Is(err, context.DeadlineExceeded):
+		return ErrCategoryTimeout
+	case errors.Is(err, cql.ErrNoConnections):
+		return ErrCategoryNoHosts
+	case errors.Is(err, cql.ErrUnavailable):
+		return ErrCategoryUnavailable
+	case errors.Is(err, cql.ErrNotFound):
+		return ErrCategoryNotFound
+	case errors.Is(err, context.Canceled):
+		return ErrCategoryCanceled
+	}
+
+	var (
+		writeTimeout *cql.RequestErrWriteTimeout
+		readTimeout  *cql.RequestErrReadTimeout
+		unavailable  *cql.RequestErrUnavailable
+		schemaErr    *SchemaError
+		identErr     *IdentifierError
+		typeErr      *TypeError
+		reqErr       cql.RequestError
+	)
+	switch {
+	case errors.As(err, &writeTimeout), errors.As(err, &readTimeout):
+		return ErrCategoryTimeout
+	case errors.As(err, &unavailable):
+		return ErrCategoryUnavailable
+	case errors.As(err, &schemaErr), errors.As(err, &identErr), errors.As(err, &typeErr):
+		return ErrCategoryInvalid
+	case errors.As(err, &reqErr):
+		// syntax error, unauthorized, invalid, config error.
+		if reqErr.Code() >= 0x2000 && reqErr.Code() <= 0x2300 {
+			return ErrCategoryInvalid
+		}
+	}
+
+	return ErrCategoryOther
+}
+
+var (
+	_ ExecManagerContext = (*InstrumentedExecManager)(nil)
+	_ ExecManagerV2      = (*InstrumentedExecManager)(nil)
+	_ StructExecManager  = (*InstrumentedExecManager)(nil)
+)
+
+// The exec manager wrapper which record the metrics of every call.
+// Example:
+//
+//	mgr := &InstrumentedExecManager{Next: &SessionExecManager{Session: s}, Metrics: m}
+type InstrumentedExecManager struct {
+	// The exec manager actually run the statement. If it implements
+	// ExecManagerContext, the context is passed through. If it implements
+	// ExecManagerV2, IterRows/ExecBatchCASRows are passed through.
+	Next    ExecManager
+	Metrics ExecMetrics
+}
+
+// Record the metrics of the call.
+func (im *InstrumentedExecManager) record(c interface{}, start time.Time, cas bool, applied bool, err error) {
+	var key MetricKey
+	key.Table, key.Kind = describeStatement(c)
+
+	im.Metrics.IncCount(key)
+	im.Metrics.ObserveLatency(key, time.Since(start))
+
+	if err != nil {
+		im.Metrics.IncError(key, CategorizeError(err))
+	} else if cas {
+		im.Metrics.IncCAS(key, applied)
+	}
+}
+
+// Exec the single statement
+func (im *InstrumentedExecManager) Exec(c CqlBuilder) error {
+	return im.ExecContext(context.Background(), c)
+}
+
+// Exec the batch
+func (im *InstrumentedExecManager) ExecBatch(b *BatchBuilder) error {
+	return im.ExecBatchContext(context.Background(), b)
+}
+
+//Exec query CAS
+func (im *InstrumentedExecManager) ExecCAS(c CqlBuilder, dest ...interface{}) (bool, error) {
+	return im.ExecCASContext(context.Background(), c, dest...)
+}
+
+//Batch CAS
+func (im *InstrumentedExecManager) ExecBatchCAS(b *BatchBuilder, dest ...interface{}) (applied bool, iter *cql.Iter, err error) {
+	return im.ExecBatchCASContext(context.Background(), b, dest...)
+}
+
+// run a query and fill back the result.
+func (im *InstrumentedExecManager) ExecScan(c CqlBuilder, dest ...interface{}) error {
+	return im.ExecScanContext(context.Background(), c, dest...)
+}
+
+// Return result set
+func (im *InstrumentedExecManager) Iter(c CqlBuilder, des ...interface{}) (*cql.Iter, error) {
+	return im.IterContext(context.Background(), c, des...)
+}
+
+// Exec the single statement with context.
+func (im *InstrumentedExecManager) ExecContext(ctx context.Context, c CqlBuilder) error {
+	start := time.Now()
+	var err error
+	if next, ok := im.Next.(ExecManagerContext); ok {
+		err = next.ExecContext(ctx, c)
+	} else {
+		err = im.Next.Exec(c)
+	}
+	im.record(c, start, false, false, err)
+	return err
+}
+
+// Exec the batch with context.
+func (im *InstrumentedExecManager) ExecBatchContext(ctx context.Context, b *BatchBuilder) error {
+	start := time.Now()
+	var err error
+	if next, ok := im.Next.(ExecManagerContext); ok {
+		err = next.ExecBatchContext(ctx, b)
+	} else {
+		err = im.Next.ExecBatch(b)
+	}
+	im.record(b, start, false, false, err)
+	return err
+}
+
+//Exec query CAS with context.
+func (im *InstrumentedExecManager) ExecCASContext(ctx context.Context, c CqlBuilder, dest ...interface{}) (applied bool, err error) {
+	start := time.Now()
+	if next, ok := im.Next.(ExecManagerContext); ok {
+		applied, err = next.ExecCASContext(ctx, c, dest...)
+	} else {
+		applied, err = im.Next.ExecCAS(c, dest...)
+	}
+	im.record(c, start, true, applied, err)
+	return applied, err
+}
+
+//Batch CAS with context.
+func (im *InstrumentedExecManager) ExecBatchCASContext(ctx context.Context, b *BatchBuilder, dest ...interface{}) (applied bool, iter *cql.Iter, err error) {
+	start := time.Now()
+	if next, ok := im.Next.(ExecManagerContext); ok {
+		applied, iter, err = next.ExecBatchCASContext(ctx, b, dest...)
+	} else {
+		applied, iter, err = im.Next.ExecBatchCAS(b, dest...)
+	}
+	im.record(b, start, true, applied, err)
+	return applied, iter, err
+}
+
+// run a query with context and fill back the result.
+func (im *InstrumentedExecManager) ExecScanContext(ctx context.Context, c CqlBuilder, dest ...interface{}) error {
+	start := time.Now()
+	var err error
+	if next, ok := im.Next.(ExecManagerContext); ok {
+		err = next.ExecScanContext(ctx, c, dest...)
+	} else {
+		err = im.Next.ExecScan(c, dest...)
+	}
+	im.record(c, start, false, false, err)
+	return err
+}
+
+// Return result set, the latency only cover the first page.
+func (im *InstrumentedExecManager) IterContext(ctx context.Context, c CqlBuilder, des ...interface{}) (iter *cql.Iter, err error) {
+	start := time.Now()
+	if next, ok := im.Next.(ExecManagerContext); ok {
+		iter, err = next.IterContext(ctx, c, des...)
+	} else {
+		iter, err = im.Next.Iter(c, des...)
+	}
+	im.record(c, start, false, false, err)
+	return iter, err
+}
+
+//Batch CAS, return the RowIterator.
+func (im *InstrumentedExecManager) ExecBatchCASRows(b *BatchBuilder, dest ...interface{}) (applied bool, iter RowIterator, err error) {
+	start := time.Now()
+	if next, ok := im.Next.(ExecManagerV2); ok {
+		applied, iter, err = next.ExecBatchCASRows(b, dest...)
+	} else {
+		var it *cql.Iter
+		applied, it, err = im.Next.ExecBatchCAS(b, dest...)
+		if it != nil {
+			iter = it
+		}
+	}
+	im.record(b, start, true, applied, err)
+	return applied, iter, err
+}
+
+// Return result set as RowIterator. The error of the read surfaces at Close,
+// so the query is recorded then, the latency covers the rows read.
+func (im *InstrumentedExecManager) IterRows(c CqlBuilder, des ...interface{}) (iter RowIterator, err error) {
+	start := time.Now()
+	if next, ok := im.Next.(ExecManagerV2); ok {
+		iter, err = next.IterRows(c, des...)
+	} else {
+		var it *cql.Iter
+		it, err = im.Next.Iter(c, des...)
+		if it != nil {
+			iter = it
+		}
+	}
+	if err != nil || iter == nil {
+		im.record(c, start, false, false, err)
+		return iter, err
+	}
+	return &instrumentedIterator{RowIterator: iter, im: im, c: c, start: start}, nil
+}
+
+// The iterator records the query when it's closed, with the error of the
+// iteration.
+type instrumentedIterator struct {
+	RowIterator
+	im     *InstrumentedExecManager
+	c      CqlBuilder
+	start  time.Time
+	closed bool
+}
+
+func (it *instrumentedIterator) Close() error {
+	err := it.RowIterator.Close()
+	if !it.closed {
+		it.closed = true
+		it.im.record(it.c, it.start, false, false, err)
+	}
+	return err
+}
+
+// Run the select and scan the first row into the struct.
+func (im *InstrumentedExecManager) ExecScanStruct(sel *SelectBuilder, dest interface{}) error {
+	return SelectOne(im, sel, dest)
+}
+
+// Run the select and append all the rows to the slice of struct.
+func (im *InstrumentedExecManager) IterAll(sel *SelectBuilder, dest interface{}) error {
+	return SelectAll(im, sel, dest)
+}
+
+// The default latency buckets of MemoryMetrics.
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// The in memory ExecMetrics, which is handy in UT.
+type MemoryMetrics struct {
+	// The upper bounds of the latency histogram buckets, DefaultLatencyBuckets if nil.
+	// The latency larger than the last bound goes to an extra bucket.
+	Buckets []time.Duration
+
+	mu         sync.Mutex
+	counts     map[MetricKey]int
+	histograms map[MetricKey][]int
+	applied    map[MetricKey]int
+	notApplied map[MetricKey]int
+	errors     map[MetricKey]map[ErrorCategory]int
+}
+
+func (m *MemoryMetrics) init() {
+	if m.counts == nil {
+		m.counts = map[MetricKey]int{}
+		m.histograms = map[MetricKey][]int{}
+		m.applied = map[MetricKey]int{}
+		m.notApplied = map[MetricKey]int{}
+		m.errors = map[MetricKey]map[ErrorCategory]int{}
+	}
+	if m.Buckets == nil {
+		m.Buckets = DefaultLatencyBuckets
+	}
+}
+
+func (m *MemoryMetrics) IncCount(key MetricKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	m.counts[key]++
+}
+
+func (m *MemoryMetrics) ObserveLatency(key MetricKey, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	h := m.histograms[key]
+	if h == nil {
+		h = make([]int, len(m.Buckets)+1)
+		m.histograms[key] = h
+	}
+
+	i := 0
+	for i < len(m.Buckets) && d > m.Buckets[i] {
+		i++
+	}
+	h[i]++
+}
+
+func (m *MemoryMetrics) IncCAS(key MetricKey, applied bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	if applied {
+		m.applied[key]++
+	} else {
+		m.notApplied[key]++
+	}
+}
+
+func (m *MemoryMetrics) IncError(key MetricKey, category ErrorCategory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	if m.errors[key] == nil {
+		m.errors[key] = map[ErrorCategory]int{}
+	}
+	m.errors[key][category]++
+}
+
+// The number of executed statements.
+func (m *MemoryMetrics) Count(key MetricKey) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[key]
+}
+
+// The latency histogram, one count per bucket plus the overflow bucket.
+func (m *MemoryMetrics) Histogram(key MetricKey) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int(nil), m.histograms[key]...)
+}
+
+// The number of applied and not applied CAS statements.
+func (m *MemoryMetrics) CAS(key MetricKey) (applied int, notApplied int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.applied[key], m.notApplied[key]
+}
+
+// The number of errors of the category.
+func (m *MemoryMetrics) Errors(key MetricKey, category ErrorCategory) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.errors[key][category]
+}
@@ -0,0 +1,347 @@
+package cqlbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	cql "github.com/gocql/gocql"
+)
+
+var (
+	ErrMigrationLocked  = errors.New("cqlbuilder: migration is locked by another runner")
+	errMigrationVersion = errors.New("cqlbuilder: migration version must be positive and unique")
+)
+
+// The default table recording the applied migrations, the lock table is
+// the same name with _lock suffix.
+const DefaultMigrationTable = "schema_migrations"
+
+// The schema migration, it's applied once by the version. The steps should
+// be idempotent(IF NOT EXISTS/IF EXISTS) since a failed migration is run
+// again from the first step.
+type Migration struct {
+	Version int64
+	Name    string
+	Steps   []CqlBuilder
+}
+
+// The function waits until all the nodes agree on the schema, it's called
+// after each schema step.
+type SchemaAgreementFunc func(ctx context.Context) error
+
+// The schema agreement check of the gocql session.
+func GocqlSchemaAgreement(s *cql.Session) SchemaAgreementFunc {
+	return func(ctx context.Context) error {
+		return s.AwaitSchemaAgreement(ctx)
+	}
+}
+
+// The runner applies the pending migrations in the order of version. The
+// applied versions are recorded in the migration table, and the lock row
+// inserted by LWT prevents the concurrent runners.
+// Example:
+//
+//	m := &Migrator{
+//		Mgr:       em,
+//		Table:     "app.schema_migrations",
+//		Agreement: GocqlSchemaAgreement(session),
+//		Migrations: []Migration{
+//			{Version: 1, Name: "users", Steps: []CqlBuilder{CreateTable("app.users")...}},
+//			{Version: 2, Name: "users_email", Steps: []CqlBuilder{AlterTable("app.users").AddColumn("email", Text)}},
+//		},
+//	}
+//	applied, err := m.Run(ctx)
+type Migrator struct {
+	Mgr        ExecManagerContext
+	Migrations []Migration
+	// The table recording the applied versions, ks.table is supported.
+	// Default is DefaultMigrationTable.
+	Table string
+	// The scope of the migrations, so several apps can share the table.
+	// Default is "default".
+	Scope string
+	// The schema agreement check, nil means no check.
+	Agreement SchemaAgreementFunc
+	// The dry run writes the CQL of the pending migration steps to it
+	// instead of executing them, nothing is written to the database.
+	DryRun io.Writer
+	// The owner of the lock, default is hostname:pid.
+	Owner string
+	// The TTL of the lock, the lock of a crashed runner is expired after
+	// it. The lock is renewed before every migration, so it must be longer
+	// than the slowest migration. Default is 10 minutes, it's rounded up to
+	// the seconds.
+	LockTTL time.Duration
+}
+
+func (m *Migrator) table() string {
+	if len(m.Table) == 0 {
+		return DefaultMigrationTable
+	}
+	return m.Table
+}
+
+func (m *Migrator) lockTable() string {
+	return m.table() + "_lock"
+}
+
+func (m *Migrator) scope() string {
+	if len(m.Scope) == 0 {
+		return "default"
+	}
+	return m.Scope
+}
+
+func (m *Migrator) owner() string {
+	if len(m.Owner) > 0 {
+		return m.Owner
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// The TTL seconds of the lock, at least 1: the TTL 0 is not set and the lock
+// would never expire.
+func (m *Migrator) lockTTL() int {
+	if m.LockTTL <= 0 {
+		return 600
+	}
+	return int((m.LockTTL + time.Second - 1) / time.Second)
+}
+
+// The migrations sorted by version.
+func (m *Migrator) sorted() ([]Migration, error) {
+	ms := append([]Migration(nil), m.Migrations...)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+
+	for i, mg := range ms {
+		if mg.Version <= 0 || i > 0 && ms[i-1].Version == mg.Version {
+			return nil, errMigrationVersion
+		}
+	}
+	return ms, nil
+}
+
+// The versions recorded in the migration table.
+func (m *Migrator) Applied(ctx context.Context) (map[int64]bool, error) {
+	sel := Select(m.table()).AddColumn("version").Where(Eq("scope", m.scope()))
+	iter, err := iterOfContext(ctx, m.Mgr, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[int64]bool{}
+	var v int64
+	for iter.Scan(&v) {
+		applied[v] = true
+	}
+	return applied, iter.Close()
+}
+
+// The migrations not applied yet, in the order of version.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	ms, err := m.sorted()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0, len(ms))
+	for _, mg := range ms {
+		if !applied[mg.Version] {
+			pending = append(pending, mg)
+		}
+	}
+	return pending, nil
+}
+
+// Create the migration and lock tables if not exists.
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	steps := []CqlBuilder{
+		CreateTable(m.table()).IfNotExists(true).
+			Column("scope", Text).Column("version", Bigint).
+			Column("name", Text).Column("applied_at", Timestamp).
+			PartitionKey("scope").ClusteringKey("version"),
+		CreateTable(m.lockTable()).IfNotExists(true).
+			Column("scope", Text).Column("owner", Text).
+			PartitionKey("scope"),
+	}
+
+	for _, s := range steps {
+		if err := m.exec(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exec the statement, and wait the schema agreement if it's a schema change.
+func (m *Migrator) exec(ctx context.Context, c CqlBuilder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := m.Mgr.ExecContext(ctx, c); err != nil {
+		return err
+	}
+
+	if _, kind := describeStatement(c); kind == KindSchema && m.Agreement != nil {
+		return m.Agreement(ctx)
+	}
+	return nil
+}
+
+func (m *Migrator) lock(ctx context.Context, owner string) error {
+	ins := Insert(m.lockTable()).SetValue("scope", m.scope()).SetValue("owner", owner).
+		IfNotExists(true).SetTtl(m.lockTTL())
+
+	var scope, current string
+	applied, err := m.Mgr.ExecCASContext(ctx, ins, &scope, &current)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("%w: held by %s", ErrMigrationLocked, current)
+	}
+	return nil
+}
+
+// Extend the TTL of the lock, fail if the lock is expired or taken by
+// another runner.
+func (m *Migrator) renew(ctx context.Context, owner string) error {
+	upd := Update(m.lockTable()).SetTtl(m.lockTTL()).SetValue("owner", owner).
+		Where(Eq("scope", m.scope())).If(Eq("owner", owner))
+
+	var current string
+	applied, err := m.Mgr.ExecCASContext(ctx, upd, &current)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("%w: the lock expired, held by %q", ErrMigrationLocked, current)
+	}
+	return nil
+}
+
+// The timeout of the unlock after the run.
+const unlockTimeout = 10 * time.Second
+
+func (m *Migrator) unlock(ctx context.Context, owner string) error {
+	del := Delete(m.lockTable()).Where(Eq("scope", m.scope())).If(Eq("owner", owner))
+
+	var current string
+	_, err := m.Mgr.ExecCASContext(ctx, del, &current)
+	return err
+}
+
+// Apply the pending migrations, return the applied ones. The migration
+// failed is not recorded, the later ones are not run. For the dry run, the
+// pending ones are returned.
+func (m *Migrator) Run(ctx context.Context) (applied []Migration, err error) {
+	if m.DryRun != nil {
+		return m.dryRun(ctx)
+	}
+
+	if _, err := m.sorted(); err != nil {
+		return nil, err
+	}
+
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+
+	owner := m.owner()
+	if err := m.lock(ctx, owner); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// The ctx may be canceled, which aborted the run, the lock is still
+		// released.
+		uctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+		if uerr := m.unlock(uctx, owner); err == nil {
+			err = uerr
+		}
+	}()
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for n, mg := range pending {
+		// The lock is fresh for the first one.
+		if n > 0 {
+			if err := m.renew(ctx, owner); err != nil {
+				return applied, err
+			}
+		}
+
+		for i, s := range mg.Steps {
+			if err := m.exec(ctx, s); err != nil {
+				return applied, fmt.Errorf("cqlbuilder: migration %d %s step %d: %w", mg.Version, mg.Name, i, err)
+			}
+		}
+
+		rec := Insert(m.table()).SetValue("scope", m.scope()).SetValue("version", mg.Version).
+			SetValue("name", mg.Name).SetValue("applied_at", time.Now())
+		if err := m.Mgr.ExecContext(ctx, rec); err != nil {
+			return applied, err
+		}
+		applied = append(applied, mg)
+	}
+
+	return applied, nil
+}
+
+// Write the CQL of the pending migrations. All the migrations are pending
+// if the migration table is not created yet.
+func (m *Migrator) dryRun(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if isUnknownTable(err) {
+		pending, err = m.sorted()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mg := range pending {
+		fmt.Fprintf(m.DryRun, "-- migration %d %s\n", mg.Version, mg.Name)
+		for _, s := range mg.Steps {
+			str, vals, err := s.ToQuery()
+			if err != nil {
+				return nil, fmt.Errorf("cqlbuilder: migration %d %s: %w", mg.Version, mg.Name, err)
+			}
+			fmt.Fprintf(m.DryRun, "%s;\n", strings.TrimSpace(str))
+			if len(vals) > 0 {
+				fmt.Fprintf(m.DryRun, "-- values %v\n", vals)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// Whether the error says the table doesn't exist: Cassandra answers the
+// query of unknown table with the invalid request error.
+func isUnknownTable(err error) bool {
+	if errors.Is(err, ErrFakeUnknownTable) {
+		return true
+	}
+
+	var reqErr cql.RequestError
+	if errors.As(err, &reqErr) && reqErr.Code() == cql.ErrCodeInvalid {
+		msg := strings.ToLower(reqErr.Message())
+		return strings.Contains(msg, "unconfigured table") || strings.Contains(msg, "does not exist")
+	}
+	return false
+}
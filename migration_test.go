@@ -0,0 +1,191 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 2, Name: "seed", Steps: []CqlBuilder{
+			Insert("users").SetValue("id", "admin").SetValue("name", "Admin"),
+		}},
+		{Version: 1, Name: "users", Steps: []CqlBuilder{
+			CreateTable("users").IfNotExists(true).Column("id", Text).Column("name", Text).PartitionKey("id"),
+		}},
+	}
+}
+
+func TestMigratorRun(t *testing.T) {
+	f := NewFakeExecManager()
+	agreements := 0
+	m := &Migrator{
+		Mgr:        f,
+		Migrations: testMigrations(),
+		Agreement: func(ctx context.Context) error {
+			agreements++
+			return nil
+		},
+	}
+
+	applied, err := m.Run(context.Background())
+	if err != nil || len(applied) != 2 || applied[0].Version != 1 || applied[1].Version != 2 {
+		t.Logf("applied %v err %v", applied, err)
+		t.FailNow()
+	}
+
+	// the two bookkeeping tables and the users table
+	if agreements != 3 || f.Get("users", "admin") == nil || f.Get("schema_migrations", "default", int64(2)) == nil {
+		t.Logf("agreements %d", agreements)
+		t.FailNow()
+	}
+
+	if len(f.Rows("schema_migrations_lock")) != 0 {
+		t.Logf("the lock is not released")
+		t.FailNow()
+	}
+
+	applied, err = m.Run(context.Background())
+	if err != nil || len(applied) != 0 {
+		t.Logf("applied %v err %v", applied, err)
+		t.FailNow()
+	}
+}
+
+func TestMigratorLocked(t *testing.T) {
+	f := NewFakeExecManager()
+	other := &Migrator{Mgr: f, Owner: "other"}
+	if err := other.ensureTables(context.Background()); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if err := other.lock(context.Background(), "other"); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	m := &Migrator{Mgr: f, Migrations: testMigrations()}
+	_, err := m.Run(context.Background())
+	if !errors.Is(err, ErrMigrationLocked) {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	if err := other.unlock(context.Background(), "other"); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if applied, err := m.Run(context.Background()); err != nil || len(applied) != 2 {
+		t.Logf("applied %v err %v", applied, err)
+		t.FailNow()
+	}
+}
+
+func TestMigratorRenewLock(t *testing.T) {
+	for _, c := range []struct {
+		step    time.Duration
+		applied int
+	}{
+		// The lock is renewed before every migration.
+		{6 * time.Second, 3},
+		// The migration runs longer than the TTL, the expired lock fails the next one.
+		{11 * time.Second, 1},
+	} {
+		f := NewFakeExecManager()
+		now := time.Now()
+		f.Now = func() time.Time { return now }
+
+		ms := append(testMigrations()[1:], Migration{Version: 2, Name: "orgs", Steps: []CqlBuilder{
+			CreateTable("orgs").IfNotExists(true).Column("id", Text).PartitionKey("id"),
+		}}, Migration{Version: 3, Name: "groups", Steps: []CqlBuilder{
+			CreateTable("groups").IfNotExists(true).Column("id", Text).PartitionKey("id"),
+		}})
+		m := &Migrator{Mgr: f, Migrations: ms, LockTTL: 10 * time.Second}
+		m.Agreement = func(ctx context.Context) error {
+			now = now.Add(c.step)
+			return nil
+		}
+
+		applied, err := m.Run(context.Background())
+		if len(applied) != c.applied || (c.applied == 3) != (err == nil) || err != nil && !errors.Is(err, ErrMigrationLocked) {
+			t.Logf("step %v applied %v err %v", c.step, applied, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestMigratorLockRelease(t *testing.T) {
+	// The sub-second TTL is rounded up, the TTL 0 would never expire.
+	if ttl := (&Migrator{LockTTL: 500 * time.Millisecond}).lockTTL(); ttl != 1 {
+		t.Logf("ttl %d", ttl)
+		t.FailNow()
+	}
+
+	// The run is aborted by the canceled ctx, the lock is still released.
+	f := NewFakeExecManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Migrator{Mgr: f, Migrations: testMigrations()}
+	m.Agreement = func(ctx context.Context) error {
+		if len(f.Rows("schema_migrations_lock")) > 0 {
+			cancel()
+		}
+		return nil
+	}
+
+	if _, err := m.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if len(f.Rows("schema_migrations_lock")) != 0 {
+		t.Logf("the lock is not released")
+		t.FailNow()
+	}
+}
+
+func TestMigratorFailedStep(t *testing.T) {
+	f := NewFakeExecManager()
+	ms := append(testMigrations(), Migration{Version: 3, Name: "bad", Steps: []CqlBuilder{Insert("missing").SetValue("id", 1)}})
+	m := &Migrator{Mgr: f, Migrations: ms}
+
+	applied, err := m.Run(context.Background())
+	if !errors.Is(err, ErrFakeUnknownTable) || len(applied) != 2 || f.Get("schema_migrations", "default", int64(3)) != nil {
+		t.Logf("applied %v err %v", applied, err)
+		t.FailNow()
+	}
+
+	m.Migrations = append(m.Migrations, Migration{Version: 1, Name: "dup"})
+	if _, err := m.Run(context.Background()); err != errMigrationVersion {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	f := NewFakeExecManager()
+	var out bytes.Buffer
+	m := &Migrator{Mgr: f, Migrations: testMigrations(), DryRun: &out}
+
+	pending, err := m.Run(context.Background())
+	expected := "-- migration 1 users\nCREATE TABLE IF NOT EXISTS users (id text,name text,PRIMARY KEY (id));\n" +
+		"-- migration 2 seed\nINSERT INTO users(id,name) VALUES(?,?);\n-- values [admin Admin]\n"
+	if err != nil || len(pending) != 2 || out.String() != expected {
+		t.Logf("pending %v err %v out %s", pending, err, out.String())
+		t.FailNow()
+	}
+
+	if len(f.Rows("users")) != 0 || len(f.Rows("schema_migrations")) != 0 {
+		t.Logf("dry run should not write")
+		t.FailNow()
+	}
+
+	// Only the missing table means all pending.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.Run(ctx); err != context.Canceled {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+}
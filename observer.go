@@ -0,0 +1,135 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+)
+
+// The exec call which run the statement.
+type ExecOp string
+
+const (
+	OpExec         ExecOp = "Exec"
+	OpExecBatch    ExecOp = "ExecBatch"
+	OpExecCAS      ExecOp = "ExecCAS"
+	OpExecBatchCAS ExecOp = "ExecBatchCAS"
+	OpExecScan     ExecOp = "ExecScan"
+	OpIter         ExecOp = "Iter"
+)
+
+// The event of an executed statement.
+type ExecEvent struct {
+	// The exec call.
+	Op ExecOp
+	// The kind of statement.
+	Kind StatementKind
+	// The table, for batch it's the tables joined by comma.
+	Table string
+	// The CQL text, for batch it's BEGIN BATCH ... APPLY BATCH.
+	Statement string
+	// The bound values, redacted if SessionExecManager.Redact is set.
+	Values []interface{}
+	// How long the call take. For IterRows it covers the iteration until
+	// Close, for Iter it only covers the first page.
+	Duration time.Duration
+	// Whether the CAS statement is applied, only for ExecCAS/ExecBatchCAS.
+	Applied bool
+	// The error returned by the call. For IterRows it's the error of Close.
+	Err error
+}
+
+// The observer receives an event for every statement executed by
+// SessionExecManager, it's the hook for logging and tracing.
+type ExecObserver interface {
+	ObserveExec(ctx context.Context, e *ExecEvent)
+}
+
+// The function to mask sensitive values before they reach the observer.
+// The returned slice is used as ExecEvent.Values, the values passed in
+// must not be modified since they are bound to the query.
+type ValueRedactor func(table string, kind StatementKind, values []interface{}) []interface{}
+
+// The observer writes the events to the standard log package.
+type LogObserver struct {
+	// The logger to write, the standard logger is used if nil.
+	Logger *log.Logger
+	// Only log the failed statements.
+	ErrorsOnly bool
+}
+
+func (l *LogObserver) ObserveExec(ctx context.Context, e *ExecEvent) {
+	if l.ErrorsOnly && e.Err == nil {
+		return
+	}
+
+	format := "cqlbuilder: %s %s %s took %s applied=%t err=%v cql=%q values=%v"
+	args := []interface{}{e.Op, e.Kind, e.Table, e.Duration, e.Applied, e.Err, e.Statement, e.Values}
+	if l.Logger != nil {
+		l.Logger.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// Build the event and pass it to the observer, and record the statement
+// to the registry.
+func (em *SessionExecManager) observe(ctx context.Context, op ExecOp, c interface{}, start time.Time, applied bool, err error) {
+	d := time.Since(start)
+	em.register(ctx, c, d, err)
+
+	if em.Observer == nil {
+		return
+	}
+
+	e := ExecEvent{
+		Op:       op,
+		Duration: d,
+		Applied:  applied,
+		Err:      err,
+	}
+	e.Table, e.Kind = describeStatement(c)
+	e.Statement, e.Values = statementText(c)
+
+	if em.Redact != nil {
+		e.Values = em.Redact(e.Table, e.Kind, e.Values)
+	}
+
+	em.Observer.ObserveExec(ctx, &e)
+}
+
+// Get the CQL text and values of the builder or batch as ExecEvent has
+// them, the text is empty if the builder fail to build.
+func StatementText(c interface{}) (string, []interface{}) {
+	return statementText(c)
+}
+
+// Get the CQL text and values of the builder or batch, the text is empty
+// if the builder fail to build.
+func statementText(c interface{}) (string, []interface{}) {
+	if b, ok := c.(*BatchBuilder); ok {
+		var buf bytes.Buffer
+		var values []interface{}
+		buf.WriteString("BEGIN BATCH ")
+		for _, q := range b.builders {
+			str, vals, err := q.ToQuery()
+			if err != nil {
+				continue
+			}
+			buf.WriteString(str)
+			buf.WriteString(";")
+			values = append(values, vals...)
+		}
+		buf.WriteString(" APPLY BATCH")
+		return buf.String(), values
+	}
+
+	if cb, ok := c.(CqlBuilder); ok {
+		str, vals, err := cb.ToQuery()
+		if err == nil {
+			return str, vals
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,172 @@
+package cqlbuilder
+
+import (
+	cql "github.com/gocql/gocql"
+)
+
+// The per statement query options, which can be attached to any builder or
+// batch by SetOptions. Only the options have been set are applied, others
+// keep the session default.
+// Example:
+//
+//	sel := Select("t").AddColumn("c1").Where(Eq("c2", 1)).SetOptions(Options().Consistency(cql.Quorum))
+type QueryOptions struct {
+	consistency       cql.Consistency
+	serialConsistency cql.SerialConsistency
+	pageSize          int
+	pageState         []byte
+	idempotent        bool
+	retryPolicy       cql.RetryPolicy
+	defaultTimestamp  bool
+	timestamp         int64
+	trace             cql.Tracer
+
+	hasConsistency       bool
+	hasSerialConsistency bool
+	hasDefaultTimestamp  bool
+	hasTimestamp         bool
+}
+
+// Create query options.
+func Options() *QueryOptions {
+	return &QueryOptions{}
+}
+
+// Set the consistency level.
+func (o *QueryOptions) Consistency(c cql.Consistency) *QueryOptions {
+	o.consistency = c
+	o.hasConsistency = true
+	return o
+}
+
+// Set the serial consistency used by the CAS(IF clause) statement.
+func (o *QueryOptions) SerialConsistency(c cql.SerialConsistency) *QueryOptions {
+	o.serialConsistency = c
+	o.hasSerialConsistency = true
+	return o
+}
+
+// Set the page size, only apply to query(not batch).
+func (o *QueryOptions) PageSize(n int) *QueryOptions {
+	o.pageSize = n
+	return o
+}
+
+// Set the page state to resume the query from, see ExecPage.
+func (o *QueryOptions) PageState(state []byte) *QueryOptions {
+	o.pageState = state
+	return o
+}
+
+// Mark the statement idempotent, so it can be retried/speculative executed.
+func (o *QueryOptions) Idempotent(i bool) *QueryOptions {
+	o.idempotent = i
+	return o
+}
+
+// Set the retry policy.
+func (o *QueryOptions) RetryPolicy(r cql.RetryPolicy) *QueryOptions {
+	o.retryPolicy = r
+	return o
+}
+
+// Enable/disable the client side default timestamp.
+func (o *QueryOptions) DefaultTimestamp(enable bool) *QueryOptions {
+	o.defaultTimestamp = enable
+	o.hasDefaultTimestamp = true
+	return o
+}
+
+// Set the write timestamp(microseconds) of the statement.
+func (o *QueryOptions) Timestamp(ts int64) *QueryOptions {
+	o.timestamp = ts
+	o.hasTimestamp = true
+	return o
+}
+
+// Set the tracer.
+func (o *QueryOptions) Trace(t cql.Tracer) *QueryOptions {
+	o.trace = t
+	return o
+}
+
+// Apply the options to the query.
+func (o *QueryOptions) applyQuery(q SessionQuery) SessionQuery {
+	if o == nil {
+		return q
+	}
+
+	if o.hasConsistency {
+		q = q.Consistency(o.consistency)
+	}
+	if o.hasSerialConsistency {
+		q = q.SerialConsistency(o.serialConsistency)
+	}
+	if o.pageSize > 0 {
+		q = q.PageSize(o.pageSize)
+	}
+	if len(o.pageState) > 0 {
+		q = q.PageState(o.pageState)
+	}
+	if o.idempotent {
+		q = q.Idempotent(true)
+	}
+	if o.retryPolicy != nil {
+		q = q.RetryPolicy(o.retryPolicy)
+	}
+	if o.hasDefaultTimestamp {
+		q = q.DefaultTimestamp(o.defaultTimestamp)
+	}
+	if o.hasTimestamp {
+		q = q.WithTimestamp(o.timestamp)
+	}
+	if o.trace != nil {
+		q = q.Trace(o.trace)
+	}
+
+	return q
+}
+
+// Apply the options to the batch.
+func (o *QueryOptions) applyBatch(b SessionBatch) SessionBatch {
+	if o == nil {
+		return b
+	}
+
+	if o.hasConsistency {
+		b = b.Consistency(o.consistency)
+	}
+	if o.hasSerialConsistency {
+		b = b.SerialConsistency(o.serialConsistency)
+	}
+	if o.idempotent {
+		b = b.Idempotent(true)
+	}
+	if o.retryPolicy != nil {
+		b = b.RetryPolicy(o.retryPolicy)
+	}
+	if o.hasDefaultTimestamp {
+		b = b.DefaultTimestamp(o.defaultTimestamp)
+	}
+	if o.hasTimestamp {
+		b = b.WithTimestamp(o.timestamp)
+	}
+	if o.trace != nil {
+		b = b.Trace(o.trace)
+	}
+
+	return b
+}
+
+// The builder which carries the query options.
+type optionsCarrier interface {
+	queryOptions() *QueryOptions
+}
+
+// Get the query options of the builder, nil if no options attached.
+func optionsOf(c interface{}) *QueryOptions {
+	if oc, ok := c.(optionsCarrier); ok {
+		return oc.queryOptions()
+	}
+	return nil
+}
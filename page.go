@@ -0,0 +1,106 @@
+package cqlbuilder
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+var ErrInvalidPageToken = errors.New("cqlbuilder: invalid page token")
+
+// Encode the page state into the token safe for url and json, empty if no
+// more pages.
+func EncodePageToken(state []byte) string {
+	if len(state) == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(state)
+}
+
+// Decode the token created by EncodePageToken, empty token is the first page.
+func DecodePageToken(token string) ([]byte, error) {
+	if len(token) == 0 {
+		return nil, nil
+	}
+
+	state, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	return state, nil
+}
+
+// The iterator stops at the end of the current page, so the driver don't
+// fetch the next page.
+type pageIterator struct {
+	RowIterator
+	left int
+}
+
+func (p *pageIterator) Scan(dest ...interface{}) bool {
+	if p.left <= 0 {
+		return false
+	}
+	if !p.RowIterator.Scan(dest...) {
+		return false
+	}
+	p.left--
+	return true
+}
+
+func (p *pageIterator) MapScan(m map[string]interface{}) bool {
+	if p.left <= 0 {
+		return false
+	}
+	if !p.RowIterator.MapScan(m) {
+		return false
+	}
+	p.left--
+	return true
+}
+
+// Run one page of the select. The token is returned by the previous page,
+// empty for the first page. Return the rows of the page and the token of
+// the next page, which is empty on the last page. The select itself is not
+// modified.
+// Example:
+//
+//	iter, next, err := ExecPage(mgr, sel, 50, req.PageToken)
+func ExecPage(mgr ExecManager, sel *SelectBuilder, pageSize int, token string) (RowIterator, string, error) {
+	state, err := DecodePageToken(token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	opts := Options()
+	if sel.options != nil {
+		o := *sel.options
+		opts = &o
+	}
+	opts.PageSize(pageSize).PageState(state)
+
+	paged := *sel
+	paged.options = opts
+
+	iter, err := iterOf(mgr, &paged)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := EncodePageToken(iter.PageState())
+	return &pageIterator{RowIterator: iter, left: iter.NumRows()}, next, nil
+}
+
+// Run one page of the select and return the rows as []T, see ExecPage and Query.
+func QueryPage[T any](mgr ExecManager, sel *SelectBuilder, pageSize int, token string) ([]T, string, error) {
+	var zero T
+	iter, next, err := ExecPage(mgr, sel.withStructColumns(&zero), pageSize, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var rows []T
+	if err := ScanAll(iter, &rows); err != nil {
+		return nil, "", err
+	}
+	return rows, next, nil
+}
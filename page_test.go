@@ -0,0 +1,56 @@
+package cqlbuilder
+
+import (
+	"testing"
+)
+
+func TestPageToken(t *testing.T) {
+	state := []byte{0x00, 0xff, 0xfe, '/', '+'}
+	token := EncodePageToken(state)
+	back, err := DecodePageToken(token)
+	if err != nil || string(back) != string(state) {
+		t.Logf("token %s back %v err %v", token, back, err)
+		t.FailNow()
+	}
+
+	if _, err := DecodePageToken("not base64!"); err != ErrInvalidPageToken {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+}
+
+func TestQueryPage(t *testing.T) {
+	fake := NewFakeExecManager().DefineTable("t", "org", "id")
+	for i := 0; i < 5; i++ {
+		fake.Exec(Insert("t").SetValue("org", 1).SetValue("id", i))
+	}
+
+	type row struct {
+		ID int `cql:"id"`
+	}
+
+	sel := Select("t").Where(Eq("org", 1))
+	var ids []int
+	token := ""
+	pages := 0
+	for {
+		rows, next, err := QueryPage[row](fake, sel, 2, token)
+		if err != nil {
+			t.Logf("err %v", err)
+			t.FailNow()
+		}
+		for _, r := range rows {
+			ids = append(ids, r.ID)
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	if pages != 3 || len(ids) != 5 || ids[4] != 4 || len(sel.colums) != 0 {
+		t.Logf("pages %d ids %v columns %v", pages, ids, sel.colums)
+		t.FailNow()
+	}
+}
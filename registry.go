@@ -0,0 +1,211 @@
+package cqlbuilder
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The optional interface of the Session driver to prepare the statement
+// ahead, the gocql adapter implements it.
+type StatementPreparer interface {
+	Prepare(ctx context.Context, stmt string) error
+}
+
+// The statistics of a CQL shape.
+type ShapeStats struct {
+	Statement    string
+	Count        int64
+	Errors       int64
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+	// The shape has been prepared by the StatementPreparer.
+	Prepared bool
+	// Why the shape looks like to grow without bound, empty if it looks fine.
+	Suspicious string
+}
+
+// The average latency of the shape.
+func (s ShapeStats) AvgLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Count)
+}
+
+var (
+	limitLiteral  = regexp.MustCompile(`(?i)\bLIMIT\s+\d+`)
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteral = regexp.MustCompile(`(=|>|<|\bIN)\s*\(?\s*-?\d+(\.\d+)?\b`)
+)
+
+// Find why the statement looks like to be built with inline values, which
+// makes the number of shapes unbounded.
+func suspiciousShape(stmt string) string {
+	switch {
+	case stringLiteral.MatchString(stmt):
+		return "inline string literal"
+	case numberLiteral.MatchString(stmt):
+		return "inline numeric literal"
+	case limitLiteral.MatchString(stmt):
+		return "inline LIMIT literal"
+	}
+	return ""
+}
+
+// The registry of the CQL shapes executed by SessionExecManager. Builders
+// produce identical CQL for identical shapes, so the number of shapes is
+// expected to be small and stable.
+type StatementRegistry struct {
+	// The executions after which the shape is prepared, if the driver
+	// implements StatementPreparer. 0 disable the prepare.
+	HotThreshold int64
+	// The number of shapes considered as unbounded growth, 0 means no limit.
+	MaxShapes int
+	// Called once when the number of shapes exceeds MaxShapes.
+	OnUnbounded func(shapes int)
+	// Called once per shape which looks built with inline values.
+	OnSuspicious func(s ShapeStats)
+
+	mu         sync.Mutex
+	shapes     map[string]*ShapeStats
+	overflowed bool
+}
+
+// Record one execution of the statement, return true if the shape just
+// becomes hot and should be prepared.
+func (r *StatementRegistry) record(stmt string, d time.Duration, err error) bool {
+	r.mu.Lock()
+
+	if r.shapes == nil {
+		r.shapes = map[string]*ShapeStats{}
+	}
+
+	var suspicious *ShapeStats
+	overflow := 0
+	s := r.shapes[stmt]
+	if s == nil {
+		s = &ShapeStats{Statement: stmt, Suspicious: suspiciousShape(stmt)}
+		r.shapes[stmt] = s
+		if s.Suspicious != "" {
+			c := *s
+			suspicious = &c
+		}
+		if r.MaxShapes > 0 && len(r.shapes) > r.MaxShapes && !r.overflowed {
+			r.overflowed = true
+			overflow = len(r.shapes)
+		}
+	}
+
+	s.Count++
+	s.TotalLatency += d
+	if d > s.MaxLatency {
+		s.MaxLatency = d
+	}
+	if err != nil {
+		s.Errors++
+	}
+
+	hot := r.HotThreshold > 0 && s.Count == r.HotThreshold && !s.Prepared
+	r.mu.Unlock()
+
+	// The callbacks run without the lock.
+	if suspicious != nil && r.OnSuspicious != nil {
+		r.OnSuspicious(*suspicious)
+	}
+	if overflow > 0 && r.OnUnbounded != nil {
+		r.OnUnbounded(overflow)
+	}
+
+	return hot
+}
+
+func (r *StatementRegistry) markPrepared(stmt string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s := r.shapes[stmt]; s != nil {
+		s.Prepared = true
+	}
+}
+
+// The statistics of the shapes, most executed first.
+func (r *StatementRegistry) Shapes() []ShapeStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ret := make([]ShapeStats, 0, len(r.shapes))
+	for _, s := range r.shapes {
+		ret = append(ret, *s)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		return ret[i].Statement < ret[j].Statement
+	})
+	return ret
+}
+
+// The statistics of the shape, false if never executed.
+func (r *StatementRegistry) Shape(stmt string) (ShapeStats, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s := r.shapes[stmt]; s != nil {
+		return *s, true
+	}
+	return ShapeStats{}, false
+}
+
+// The shapes which look built with inline values.
+func (r *StatementRegistry) Suspicious() []ShapeStats {
+	var ret []ShapeStats
+	for _, s := range r.Shapes() {
+		if s.Suspicious != "" {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+// Whether the number of shapes exceeds MaxShapes.
+func (r *StatementRegistry) Unbounded() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.overflowed
+}
+
+// Record the executed builder or batch to the registry, and prepare the hot
+// shapes.
+func (em *SessionExecManager) register(ctx context.Context, c interface{}, d time.Duration, err error) {
+	if em.Registry == nil {
+		return
+	}
+
+	var stmts []CqlBuilder
+	if b, ok := c.(*BatchBuilder); ok {
+		stmts = b.builders
+	} else if cb, ok := c.(CqlBuilder); ok {
+		stmts = []CqlBuilder{cb}
+	}
+
+	if len(stmts) == 0 {
+		return
+	}
+
+	// The latency of the batch is shared by its statements.
+	share := d / time.Duration(len(stmts))
+	for _, cb := range stmts {
+		str, _, buildErr := cb.ToQuery()
+		if buildErr != nil {
+			continue
+		}
+
+		if em.Registry.record(str, share, err) {
+			if p, ok := em.driver().(StatementPreparer); ok && p.Prepare(ctx, str) == nil {
+				em.Registry.markPrepared(str)
+			}
+		}
+	}
+}
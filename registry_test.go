@@ -0,0 +1,109 @@
+package cqlbuilder
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// The driver which records the prepared statements.
+type prepareSession struct {
+	recordSession
+	prepared []string
+}
+
+func (p *prepareSession) Prepare(ctx context.Context, stmt string) error {
+	p.prepared = append(p.prepared, stmt)
+	return nil
+}
+
+func TestStatementRegistry(t *testing.T) {
+	driver := &prepareSession{}
+	unbounded := 0
+	reg := &StatementRegistry{HotThreshold: 2, MaxShapes: 2, OnUnbounded: func(n int) { unbounded = n }}
+	em := &SessionExecManager{Driver: driver, Registry: reg}
+
+	up := Update("t").SetValue("c1", 1).Where(Eq("id", 1))
+	for i := 0; i < 3; i++ {
+		em.Exec(up)
+	}
+
+	// The inline LIMIT makes a shape per limit.
+	for i := 1; i <= 2; i++ {
+		em.Exec(Select("t").AddColumn("c1").Where(Eq("id", 1)).SetLimit(i))
+	}
+
+	shapes := reg.Shapes()
+	if len(shapes) != 3 || shapes[0].Count != 3 || !shapes[0].Prepared || len(driver.prepared) != 1 {
+		t.Logf("shapes %+v prepared %v", shapes, driver.prepared)
+		t.FailNow()
+	}
+
+	if !reg.Unbounded() || unbounded != 3 || len(reg.Suspicious()) != 2 {
+		t.Logf("unbounded %v %d suspicious %+v", reg.Unbounded(), unbounded, reg.Suspicious())
+		t.FailNow()
+	}
+}
+
+func TestSuspiciousShape(t *testing.T) {
+	cases := map[string]string{
+		" SELECT a FROM t WHERE id=?":          "",
+		" SELECT a FROM t WHERE id='abc'":      "inline string literal",
+		" SELECT a FROM t WHERE id=123":        "inline numeric literal",
+		" SELECT a FROM t WHERE id=? LIMIT 10": "inline LIMIT literal",
+		" SELECT a FROM t WHERE token(id)>?":   "",
+	}
+	for stmt, expected := range cases {
+		if got := suspiciousShape(stmt); got != expected {
+			t.Logf("%s: got %q expected %q", stmt, got, expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestGocqlPrepare(t *testing.T) {
+	driver := NewGocqlSession(nil)
+	p, ok := driver.(StatementPreparer)
+	if !ok {
+		t.Logf("gocql adapter can't prepare")
+		t.FailNow()
+	}
+
+	// gocql would exec the DDL instead of preparing it, so the session is never touched.
+	if err := p.Prepare(context.Background(), " CREATE TABLE t (id int PRIMARY KEY)"); err != errNotPreparable {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	cases := map[string]bool{
+		" SELECT a FROM t WHERE id=?":   true,
+		"insert INTO t (id) VALUES (?)": true,
+		" UPDATE t SET a=? WHERE id=?":  true,
+		" DELETE FROM t WHERE id=?":     true,
+		" TRUNCATE t":                   false,
+		"SELECT":                        false,
+	}
+	for stmt, expected := range cases {
+		if preparable(stmt) != expected {
+			t.Logf("%s: expected %v", stmt, expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestBatchLatency(t *testing.T) {
+	reg := &StatementRegistry{}
+	em := &SessionExecManager{Driver: &recordSession{}, Registry: reg}
+
+	batch := StartBatch()
+	batch.Add(Insert("t").SetValue("id", 1))
+	batch.Add(Insert("t").SetValue("id", 2))
+	batch.Add(Update("t").SetValue("a", 1).Where(Eq("id", 1)))
+	em.register(context.Background(), batch, 3*time.Millisecond, nil)
+
+	shapes := reg.Shapes()
+	if len(shapes) != 2 || shapes[0].TotalLatency != 2*time.Millisecond || shapes[1].TotalLatency != time.Millisecond {
+		t.Logf("shapes %+v", shapes)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,440 @@
+package cqlbuilder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cql "github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+)
+
+// The literal of the redacted value.
+const redacted = "'***'"
+
+// The options of rendering the statement with the values.
+type RenderOptions struct {
+	// The strings longer than it(runes) and the blobs longer than it(bytes)
+	// are cut, the collections with more elements are cut too. The cut
+	// ones end with "...". 0 means no limit.
+	Truncate int
+	// The values of the columns are rendered as '***'.
+	Redact []string
+	// The schemas to render the values by the column types, e.g. the set
+	// or the tuple. The schema bound to the builder is used first.
+	Schemas *SchemaRegistry
+}
+
+// The column a bind marker is bound to.
+type bindInfo struct {
+	column string
+	// The IN ?, the value is the list of the values.
+	in bool
+}
+
+// The columns of the values returned by ToQuery, in the same order. The TTL
+// has no column.
+func bindsOf(c CqlBuilder) []bindInfo {
+	var binds []bindInfo
+	switch b := c.(type) {
+	case *InsertBuilder:
+		for _, col := range b.colums {
+			binds = append(binds, bindInfo{column: col})
+		}
+		if b.ttl > 0 {
+			binds = append(binds, bindInfo{})
+		}
+	case *UpdateBuilder:
+		if b.ttl > 0 {
+			binds = append(binds, bindInfo{})
+		}
+		for _, col := range b.colums {
+			binds = append(binds, bindInfo{column: col})
+		}
+		binds = appendConditionBinds(binds, b.whereConditions)
+		binds = appendConditionBinds(binds, b.ifConditions)
+	case *DeleteBuilder:
+		binds = appendConditionBinds(binds, b.whereConditions)
+		binds = appendConditionBinds(binds, b.ifConditions)
+	case *SelectBuilder:
+		binds = appendConditionBinds(binds, b.whereConditions)
+	}
+	return binds
+}
+
+func appendConditionBinds(binds []bindInfo, conds []conditionBuilder) []bindInfo {
+	for _, cond := range conds {
+		switch c := cond.(type) {
+		case *eqBuilder:
+			binds = append(binds, bindInfo{column: c.column})
+		case *inBuilder:
+			binds = append(binds, bindInfo{column: c.column, in: true})
+		case *cmpBuilder:
+			for i := range c.values {
+				col := ""
+				if i < len(c.columns) {
+					col = c.columns[i]
+				}
+				binds = append(binds, bindInfo{column: col})
+			}
+		}
+	}
+	return binds
+}
+
+// Render the statement with the values as the CQL literals, for the logs and
+// the error messages. The options can be nil.
+// Example:
+//
+//	Render(Select("users").AddColumn("name").Where(Eq("id", 1)).Where(In("org", []string{"a", "b"})), nil)
+//	// SELECT name FROM users WHERE id=1 AND org in ('a','b')
+func Render(c CqlBuilder, o *RenderOptions) (string, error) {
+	if o == nil {
+		o = &RenderOptions{}
+	}
+
+	str, values, err := c.ToQuery()
+	if err != nil {
+		return "", err
+	}
+
+	table, _ := describeStatement(c)
+	schema := schemaOfBuilder(c, o.Schemas, table)
+	binds := bindsOf(c)
+	if len(binds) != len(values) {
+		binds = make([]bindInfo, len(values))
+	}
+
+	literals := make([]string, len(values))
+	for i, v := range values {
+		literals[i] = o.bindLiteral(schema, binds[i], v)
+	}
+	return interpolate(strings.TrimSpace(str), literals), nil
+}
+
+// Render the batch, sth like BEGIN BATCH stmt1; stmt2; APPLY BATCH.
+func RenderBatch(b *BatchBuilder, o *RenderOptions) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("BEGIN BATCH ")
+	for _, c := range b.builders {
+		str, err := Render(c, o)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(str + "; ")
+	}
+	buf.WriteString("APPLY BATCH")
+	return buf.String(), nil
+}
+
+// Render the statement with the default options, the error is rendered if
+// the statement can't be built.
+func Debug(c CqlBuilder) string {
+	str, err := Render(c, nil)
+	if err != nil {
+		return fmt.Sprintf("<invalid statement: %v>", err)
+	}
+	return str
+}
+
+// Replace the bind markers with the literals, the ? in the string literals
+// and the quoted identifiers are kept.
+func interpolate(stmt string, literals []string) string {
+	var buf strings.Builder
+	n := 0
+	var quote byte
+	for i := 0; i < len(stmt); i++ {
+		ch := stmt[i]
+		switch {
+		case quote != 0:
+			// The doubled quote is the escaped one, it closes and opens again.
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '?' && n < len(literals):
+			buf.WriteString(literals[n])
+			n++
+			continue
+		}
+		buf.WriteByte(ch)
+	}
+	return buf.String()
+}
+
+func (o *RenderOptions) isRedacted(column string) bool {
+	for _, c := range o.Redact {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *RenderOptions) bindLiteral(schema *TableSchema, bind bindInfo, v interface{}) string {
+	if len(bind.column) > 0 && o.isRedacted(bind.column) {
+		return redacted
+	}
+
+	var typ *CqlType
+	if schema != nil && len(bind.column) > 0 {
+		if col, ok := schema.Column(bind.column); ok {
+			typ = &col.Type
+		}
+	}
+
+	if bind.in {
+		// IN (a,b), each value has the column type.
+		return o.elements(reflect.ValueOf(v), "(", ")", func(int) *CqlType { return typ })
+	}
+	return o.literal(v, typ)
+}
+
+// The CQL literal of the value, the type is nil if unknown.
+func (o *RenderOptions) literal(v interface{}, typ *CqlType) string {
+	if typ != nil && typ.Name == "frozen" && len(typ.Params) == 1 {
+		typ = &typ.Params[0]
+	}
+
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return o.stringLiteral(x)
+	case []byte:
+		if x == nil {
+			return "null"
+		}
+		if o.Truncate > 0 && len(x) > o.Truncate {
+			return "0x" + hex.EncodeToString(x[:o.Truncate]) + "..."
+		}
+		return "0x" + hex.EncodeToString(x)
+	case time.Time:
+		if typ != nil && typ.Name == Date.Name {
+			return "'" + x.UTC().Format("2006-01-02") + "'"
+		}
+		return "'" + x.UTC().Format("2006-01-02 15:04:05.000") + "+0000'"
+	case time.Duration:
+		if typ != nil && typ.Name == Time.Name {
+			return timeLiteral(x)
+		}
+		return durationLiteral(0, 0, int64(x))
+	case cql.Duration:
+		return durationLiteral(x.Months, x.Days, x.Nanoseconds)
+	case cql.UUID:
+		return x.String()
+	case net.IP:
+		if x == nil {
+			return "null"
+		}
+		return "'" + x.String() + "'"
+	case *big.Int:
+		if x == nil {
+			return "null"
+		}
+		return x.String()
+	case *inf.Dec:
+		if x == nil {
+			return "null"
+		}
+		return x.String()
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return "null"
+		}
+		return o.literal(rv.Elem().Interface(), typ)
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32:
+		return floatLiteral(rv.Float(), 32)
+	case reflect.Float64:
+		return floatLiteral(rv.Float(), 64)
+	case reflect.String:
+		return o.stringLiteral(rv.String())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return "null"
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return o.literal(b, typ)
+		}
+		return o.sequence(rv, typ)
+	case reflect.Map:
+		if rv.IsNil() {
+			return "null"
+		}
+		return o.mapLiteral(rv, typ)
+	case reflect.Struct:
+		return o.structLiteral(rv)
+	}
+	return o.stringLiteral(fmt.Sprint(v))
+}
+
+func (o *RenderOptions) stringLiteral(s string) string {
+	cut := ""
+	if o.Truncate > 0 && len(s) > o.Truncate {
+		if r := []rune(s); len(r) > o.Truncate {
+			s, cut = string(r[:o.Truncate]), "..."
+		}
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + cut + "'"
+}
+
+func floatLiteral(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+// The duration literal, sth like 1mo2d3h4m5s6ms.
+func durationLiteral(months, days int32, nanos int64) string {
+	if months == 0 && days == 0 && nanos == 0 {
+		return "0s"
+	}
+
+	var buf strings.Builder
+	if months < 0 || days < 0 || nanos < 0 {
+		buf.WriteString("-")
+		months, days, nanos = -months, -days, -nanos
+	}
+
+	write := func(n int64, unit string) {
+		if n > 0 {
+			buf.WriteString(strconv.FormatInt(n, 10) + unit)
+		}
+	}
+	write(int64(months), "mo")
+	write(int64(days), "d")
+	for _, u := range []struct {
+		d    time.Duration
+		unit string
+	}{{time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}, {time.Millisecond, "ms"}, {time.Microsecond, "us"}, {time.Nanosecond, "ns"}} {
+		write(nanos/int64(u.d), u.unit)
+		nanos %= int64(u.d)
+	}
+	return buf.String()
+}
+
+// The time literal, sth like '08:12:54.123456789'.
+func timeLiteral(d time.Duration) string {
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := d % time.Minute / time.Second
+	ns := d % time.Second
+	return fmt.Sprintf("'%02d:%02d:%02d.%09d'", h, m, s, ns)
+}
+
+// The element type of the collection or tuple type, nil if unknown.
+func paramOf(typ *CqlType, i int) *CqlType {
+	if typ == nil || i >= len(typ.Params) {
+		return nil
+	}
+	return &typ.Params[i]
+}
+
+// The list [a,b], the set {a,b} or the tuple (a,b) by the type, default is
+// the list.
+func (o *RenderOptions) sequence(rv reflect.Value, typ *CqlType) string {
+	switch {
+	case typ != nil && typ.Name == "set":
+		return o.elements(rv, "{", "}", func(int) *CqlType { return paramOf(typ, 0) })
+	case typ != nil && typ.Name == "tuple":
+		return o.elements(rv, "(", ")", func(i int) *CqlType { return paramOf(typ, i) })
+	}
+	return o.elements(rv, "[", "]", func(int) *CqlType { return paramOf(typ, 0) })
+}
+
+// The elements of the slice/array between the brackets.
+func (o *RenderOptions) elements(rv reflect.Value, open, close string, typeOf func(i int) *CqlType) string {
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return open + o.literal(rv.Interface(), typeOf(0)) + close
+	}
+
+	items := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		items = append(items, o.literal(rv.Index(i).Interface(), typeOf(i)))
+	}
+	return open + o.join(items) + close
+}
+
+// Join the items, the ones more than Truncate are cut.
+func (o *RenderOptions) join(items []string) string {
+	if o.Truncate > 0 && len(items) > o.Truncate {
+		items = append(items[:o.Truncate:o.Truncate], "...")
+	}
+	return strings.Join(items, comma)
+}
+
+// The map {k:v}, the set {k} of map[T]struct{}, or the UDT {field:v} by the
+// type. The entries are sorted for the stable output.
+func (o *RenderOptions) mapLiteral(rv reflect.Value, typ *CqlType) string {
+	isSet := typ != nil && typ.Name == "set" || typ == nil && rv.Type().Elem() == reflect.TypeOf(struct{}{})
+	isUDT := typ != nil && typ.UDT
+
+	items := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		switch {
+		case isSet:
+			items = append(items, o.literal(k.Interface(), paramOf(typ, 0)))
+		case isUDT:
+			items = append(items, udtField(fmt.Sprint(k.Interface()))+":"+o.literal(rv.MapIndex(k).Interface(), nil))
+		default:
+			items = append(items, o.literal(k.Interface(), paramOf(typ, 0))+":"+o.literal(rv.MapIndex(k).Interface(), paramOf(typ, 1)))
+		}
+	}
+	sort.Strings(items)
+	return "{" + o.join(items) + "}"
+}
+
+// The UDT {field:v} of the struct, the field name is the cql tag or the
+// lower case field name as gocql does.
+func (o *RenderOptions) structLiteral(rv reflect.Value) string {
+	t := rv.Type()
+	items := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, opts := parseTag(f.Tag.Get(structTag))
+		if name == "-" || contains(opts, "ttl") {
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(f.Name)
+		}
+		items = append(items, udtField(name)+":"+o.literal(rv.Field(i).Interface(), nil))
+	}
+	return "{" + o.join(items) + "}"
+}
+
+func udtField(name string) string {
+	if q, err := QuoteIdentifier(name); err == nil {
+		return q
+	}
+	return name
+}
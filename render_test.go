@@ -0,0 +1,106 @@
+package cqlbuilder
+
+import (
+	"math"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	cql "github.com/gocql/gocql"
+)
+
+func TestRenderLiterals(t *testing.T) {
+	uuid, _ := cql.ParseUUID("5f1c1b5e-6a2b-4b8e-9a55-2c1d4e0a9f10")
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.FixedZone("x", 3600))
+	name := "it's"
+	var nilPtr *string
+
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{"it's", "'it''s'"},
+		{&name, "'it''s'"},
+		{nilPtr, "null"},
+		{nil, "null"},
+		{[]byte{0xca, 0xfe}, "0xcafe"},
+		{true, "true"},
+		{int8(-3), "-3"},
+		{uint16(7), "7"},
+		{1.5, "1.5"},
+		{math.Inf(-1), "-Infinity"},
+		{uuid, "5f1c1b5e-6a2b-4b8e-9a55-2c1d4e0a9f10"},
+		{ts, "'2024-01-02 02:04:05.006+0000'"},
+		{90*time.Minute + 2*time.Millisecond, "1h30m2ms"},
+		{cql.Duration{Months: 1, Days: 2, Nanoseconds: 3}, "1mo2d3ns"},
+		{net.ParseIP("10.0.0.1"), "'10.0.0.1'"},
+		{big.NewInt(42), "42"},
+		{[]string{"a", "b"}, "['a','b']"},
+		{map[string]int{"b": 2, "a": 1}, "{'a':1,'b':2}"},
+		{map[int]struct{}{2: {}, 1: {}}, "{1,2}"},
+		{struct {
+			Street string `cql:"street"`
+			Zip    int
+		}{"main", 1}, "{street:'main',zip:1}"},
+		{struct {
+			Street string `cql:"street,omitempty"`
+			City   string `cql:",omitempty"`
+			TTL    int    `cql:",ttl"`
+			Skip   string `cql:"-"`
+		}{"main", "x", 60, "y"}, "{street:'main',city:'x'}"},
+	}
+
+	o := &RenderOptions{}
+	for _, c := range cases {
+		if s := o.literal(c.value, nil); s != c.expected {
+			t.Logf("literal of %#v is %s, expected %s", c.value, s, c.expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	sel := Select("users").AddColumn("name").Where(Eq("id", 1)).Where(In("org", []string{"a", "b"})).
+		Where(Gt("note", "what?"))
+	if s := Debug(sel); s != "SELECT name FROM users WHERE id=1 AND org in ('a','b') AND note>'what?'" {
+		t.Logf("rendered %s", s)
+		t.FailNow()
+	}
+
+	up := Update("users").SetTtl(60).SetValue("password", "secret").SetValue("name", "abcdefgh").
+		Where(Eq("id", 1)).If(Eq("version", 2))
+	s, err := Render(up, &RenderOptions{Redact: []string{"password"}, Truncate: 3})
+	if err != nil || s != "UPDATE users USING  TTL 60  SET password ='***' ,name ='abc...'  WHERE id=1 IF version=2" {
+		t.Logf("rendered %q err %v", s, err)
+		t.FailNow()
+	}
+
+	if s := Debug(Select("").AddColumn("a")); s != "<invalid statement: "+errEmptyTable.Error()+">" {
+		t.Logf("rendered %s", s)
+		t.FailNow()
+	}
+}
+
+func TestRenderTyped(t *testing.T) {
+	reg := NewSchemaRegistry().Register(SchemaOf(CreateTable("places").
+		Column("id", Int).Column("tags", SetOf(Text)).Column("point", TupleOf(Double, Double)).
+		Column("addr", Frozen(UDT("address"))).Column("day", Date).
+		PartitionKey("id")))
+
+	ins := Insert("places").SetValue("id", 1).SetValue("tags", []string{"a", "b", "c"}).
+		SetValue("point", []interface{}{1.5, 2}).
+		SetValue("addr", map[string]interface{}{"street": "main", "Zip": 1}).
+		SetValue("day", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	s, err := Render(ins, &RenderOptions{Schemas: reg, Truncate: 2})
+	if err != nil || s != `INSERT INTO places(id,tags,point,addr,day) VALUES(1,{'a','b',...},(1.5,2),{Zip:1,street:'ma...'},'2024-01-02')` {
+		t.Logf("rendered %s err %v", s, err)
+		t.FailNow()
+	}
+
+	b := StartBatch().Add(Delete("places").Where(Eq("id", 1))).Add(Insert("places").SetValue("id", 2))
+	if s, err := RenderBatch(b, nil); err != nil || s != "BEGIN BATCH DELETE  FROM places WHERE id=1; INSERT INTO places(id) VALUES(2); APPLY BATCH" {
+		t.Logf("rendered %s err %v", s, err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,185 @@
+package cqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// The result set iterator, *cql.Iter implements it and SliceIterator
+// is the implementation for UT.
+type RowIterator interface {
+	// Scan the next row into dest, return false if no more rows or error.
+	Scan(dest ...interface{}) bool
+	// Close the iterator and return the error happened during iteration.
+	Close() error
+	// Scan the next row into the map keyed by column name.
+	MapScan(m map[string]interface{}) bool
+	// The paging state for the next page.
+	PageState() []byte
+	// The number of rows in the current page.
+	NumRows() int
+}
+
+var _ RowIterator = (*SliceIterator)(nil)
+
+// The slice backed RowIterator, the rows are returned in order.
+// Example:
+//
+//	iter := NewSliceIterator([]string{"id", "name"}, []interface{}{1, "a"}, []interface{}{2, "b"})
+type SliceIterator struct {
+	// The column names, used by MapScan.
+	Columns []string
+	Rows    [][]interface{}
+	// The page state returned by PageState.
+	NextPageState []byte
+	// The error returned by Close.
+	Err error
+
+	pos int
+}
+
+// Create the slice backed iterator.
+func NewSliceIterator(columns []string, rows ...[]interface{}) *SliceIterator {
+	return &SliceIterator{
+		Columns: columns,
+		Rows:    rows,
+	}
+}
+
+func (s *SliceIterator) Scan(dest ...interface{}) bool {
+	if s.Err != nil || s.pos >= len(s.Rows) {
+		return false
+	}
+
+	row := s.Rows[s.pos]
+	if len(dest) != len(row) {
+		s.Err = fmt.Errorf("cqlbuilder: scan %d columns into %d destinations", len(row), len(dest))
+		return false
+	}
+
+	for i := range dest {
+		if err := assignValue(dest[i], row[i]); err != nil {
+			s.Err = err
+			return false
+		}
+	}
+
+	s.pos++
+	return true
+}
+
+func (s *SliceIterator) MapScan(m map[string]interface{}) bool {
+	if s.Err != nil || s.pos >= len(s.Rows) {
+		return false
+	}
+
+	row := s.Rows[s.pos]
+	if len(s.Columns) != len(row) {
+		s.Err = fmt.Errorf("cqlbuilder: %d columns for row of %d values", len(s.Columns), len(row))
+		return false
+	}
+
+	for i, col := range s.Columns {
+		m[col] = row[i]
+	}
+
+	s.pos++
+	return true
+}
+
+func (s *SliceIterator) Close() error {
+	s.pos = len(s.Rows)
+	return s.Err
+}
+
+func (s *SliceIterator) PageState() []byte {
+	return s.NextPageState
+}
+
+func (s *SliceIterator) NumRows() int {
+	return len(s.Rows)
+}
+
+var errNilDest = errors.New("cqlbuilder: scan destination must be a non nil pointer")
+
+// Assign the value to the pointer dest. nil value set dest to zero value,
+// the numeric values are converted between the numeric types.
+func assignValue(dest interface{}, v interface{}) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return errNilDest
+	}
+
+	elem := dv.Elem()
+	if v == nil {
+		elem.Set(reflect.Zero(elem.Type()))
+		return nil
+	}
+
+	vv := reflect.ValueOf(v)
+	if vv.Type().AssignableTo(elem.Type()) {
+		elem.Set(vv)
+		return nil
+	}
+
+	if isNumeric(vv.Kind()) && isNumeric(elem.Kind()) {
+		cv, err := convertNumber(vv, elem.Type())
+		if err != nil {
+			return err
+		}
+		elem.Set(cv)
+		return nil
+	}
+
+	return fmt.Errorf("cqlbuilder: can not scan %T into %s", v, elem.Type())
+}
+
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// Convert the number to the type, the error is returned if it overflows or
+// loses the precision, e.g. 300 into int8 or 1.5 into int.
+func convertNumber(v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	cv := v.Convert(t)
+
+	lossy := false
+	switch {
+	case isFloat(v.Kind()) && isFloat(t.Kind()) && math.IsNaN(v.Float()):
+		// NaN never equals to itself.
+	case isSigned(v.Kind()) && isUnsigned(t.Kind()):
+		lossy = v.Int() < 0 || cv.Convert(v.Type()).Int() != v.Int()
+	case isUnsigned(v.Kind()) && isSigned(t.Kind()):
+		lossy = cv.Int() < 0 || cv.Convert(v.Type()).Uint() != v.Uint()
+	case isFloat(v.Kind()) && !isFloat(t.Kind()):
+		f := v.Float()
+		lossy = math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) || cv.Convert(v.Type()).Float() != f
+	default:
+		lossy = cv.Convert(v.Type()).Interface() != v.Interface()
+	}
+
+	if lossy {
+		return reflect.Value{}, fmt.Errorf("cqlbuilder: can not scan %v(%s) into %s without overflow or loss of precision", v.Interface(), v.Type(), t)
+	}
+	return cv, nil
+}
+
+func isSigned(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUnsigned(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
@@ -0,0 +1,272 @@
+package cqlbuilder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Build the token(...) expression of the partition key columns, used with
+// Gt/Ge/Lt/Le, sth like token(c1,c2)>?
+func Token(partitionKeys ...string) string {
+	return "token(" + strings.Join(partitionKeys, comma) + ")"
+}
+
+// The Murmur3 token range (Start, End].
+type TokenRange struct {
+	Start int64
+	End   int64
+}
+
+func (r TokenRange) String() string {
+	return fmt.Sprintf("(%d,%d]", r.Start, r.End)
+}
+
+// Split the whole Murmur3 token ring into n ranges of the same width.
+func SplitTokenRing(n int) []TokenRange {
+	if n < 1 {
+		n = 1
+	}
+
+	width := uint64(math.MaxUint64) / uint64(n)
+	ranges := make([]TokenRange, n)
+	start := int64(math.MinInt64)
+	for i := 0; i < n; i++ {
+		end := int64(uint64(start) + width)
+		if i == n-1 {
+			end = math.MaxInt64
+		}
+		ranges[i] = TokenRange{Start: start, End: end}
+		start = end
+	}
+	return ranges
+}
+
+// The checkpoint of the completed ranges, so the scan can be resumed.
+type ScanCheckpoint interface {
+	// Whether the range has been completed by the previous run.
+	Completed(r TokenRange) bool
+	// Record the completed range.
+	MarkCompleted(r TokenRange) error
+}
+
+// The in memory ScanCheckpoint. The ranges can be persisted by Ranges and
+// resumed by NewMemoryCheckpoint.
+type MemoryCheckpoint struct {
+	mu   sync.Mutex
+	done map[TokenRange]bool
+}
+
+// Create the checkpoint with the ranges completed before.
+func NewMemoryCheckpoint(completed ...TokenRange) *MemoryCheckpoint {
+	m := &MemoryCheckpoint{done: map[TokenRange]bool{}}
+	for _, r := range completed {
+		m.done[r] = true
+	}
+	return m
+}
+
+func (m *MemoryCheckpoint) Completed(r TokenRange) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.done[r]
+}
+
+func (m *MemoryCheckpoint) MarkCompleted(r TokenRange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done == nil {
+		m.done = map[TokenRange]bool{}
+	}
+	m.done[r] = true
+	return nil
+}
+
+// The completed ranges.
+func (m *MemoryCheckpoint) Ranges() []TokenRange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ranges := make([]TokenRange, 0, len(m.done))
+	for r := range m.done {
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// The progress of the scan.
+type ScanProgress struct {
+	Total     int
+	Completed int
+	// The ranges skipped because they were completed by the previous run.
+	Skipped int
+	Failed  int
+}
+
+// The error of the ranges which still fail after retries.
+type ScanError struct {
+	Failed []TokenRange
+	// The last error.
+	Err error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("cqlbuilder: %d token ranges failed, last error: %v", len(e.Failed), e.Err)
+}
+
+// The handler of a token range, it should consume the rows of iter. The
+// range is retried if it return error or iter.Close fail, so the rows may be
+// handled more than once.
+type ScanFunc func(ctx context.Context, r TokenRange, iter RowIterator) error
+
+// Scan the whole table by splitting the token ring into ranges, which run
+// concurrently by a bounded worker pool. It replaces the full table select
+// with ALLOW FILTERING in backfill/export jobs.
+// Example:
+//
+//	s := &TableScanner{Mgr: mgr, Table: "users", PartitionKeys: []string{"org_id"}, Columns: []string{"org_id", "name"}}
+//	err := s.Scan(ctx, func(ctx context.Context, r TokenRange, iter RowIterator) error { ... })
+type TableScanner struct {
+	Mgr           ExecManager
+	Table         string
+	PartitionKeys []string
+	Columns       []string
+
+	// The number of token ranges, default 256.
+	Splits int
+	// The number of concurrent ranges, default 4.
+	Workers int
+	// The retries of a failed range, default 0.
+	Retries int
+	// The wait before retry.
+	RetryBackoff time.Duration
+	// The page size of the range query, default is the session's.
+	PageSize int
+
+	// Optional checkpoint to skip the completed ranges and record new ones.
+	Checkpoint ScanCheckpoint
+	// Optional callback after every range is finished.
+	Progress func(p ScanProgress)
+}
+
+// Build the select of the token range.
+func (s *TableScanner) rangeSelect(r TokenRange) *SelectBuilder {
+	tok := Token(s.PartitionKeys...)
+	sel := Select(s.Table).AddColumns(s.Columns...).Where(Gt(tok, r.Start)).Where(Le(tok, r.End))
+	if s.PageSize > 0 {
+		sel.SetOptions(Options().PageSize(s.PageSize))
+	}
+	return sel
+}
+
+// Run the scan, return *ScanError if some ranges fail after retries.
+func (s *TableScanner) Scan(ctx context.Context, fn ScanFunc) error {
+	splits, workers := s.Splits, s.Workers
+	if splits <= 0 {
+		splits = 256
+	}
+	if workers <= 0 {
+		workers = 4
+	}
+
+	ranges := SplitTokenRing(splits)
+	progress := ScanProgress{Total: len(ranges)}
+	var failed []TokenRange
+	var lastErr error
+	var mu sync.Mutex
+
+	report := func(update func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		update()
+		if s.Progress != nil {
+			s.Progress(progress)
+		}
+	}
+
+	todo := make(chan TokenRange)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range todo {
+				err := s.scanRange(ctx, r, fn)
+				report(func() {
+					if err != nil {
+						progress.Failed++
+						failed = append(failed, r)
+						lastErr = err
+					} else {
+						progress.Completed++
+					}
+				})
+			}
+		}()
+	}
+
+	for _, r := range ranges {
+		if s.Checkpoint != nil && s.Checkpoint.Completed(r) {
+			report(func() { progress.Skipped++ })
+			continue
+		}
+		if ctx.Err() != nil {
+			report(func() {
+				progress.Failed++
+				failed = append(failed, r)
+				lastErr = ctx.Err()
+			})
+			continue
+		}
+		todo <- r
+	}
+	close(todo)
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return &ScanError{Failed: failed, Err: lastErr}
+	}
+	return nil
+}
+
+// Scan one range with retries, and record the checkpoint when done.
+func (s *TableScanner) scanRange(ctx context.Context, r TokenRange, fn ScanFunc) error {
+	var err error
+	for attempt := 0; attempt <= s.Retries; attempt++ {
+		if attempt > 0 && s.RetryBackoff > 0 {
+			select {
+			case <-time.After(s.RetryBackoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var iter RowIterator
+		iter, err = iterOf(s.Mgr, s.rangeSelect(r))
+		if err != nil {
+			continue
+		}
+
+		err = fn(ctx, r, iter)
+		if closeErr := iter.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if s.Checkpoint != nil {
+		return s.Checkpoint.MarkCompleted(r)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cqlbuilder
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestSplitTokenRing(t *testing.T) {
+	ranges := SplitTokenRing(4)
+	if len(ranges) != 4 || ranges[0].Start != math.MinInt64 || ranges[3].End != math.MaxInt64 {
+		t.Logf("ranges %v", ranges)
+		t.FailNow()
+	}
+
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Start != ranges[i-1].End || ranges[i].Start >= ranges[i].End {
+			t.Logf("ranges not continuous %v", ranges)
+			t.FailNow()
+		}
+	}
+}
+
+// The exec manager returns one row per range and fails the first attempt
+// of the first range.
+type scanStub struct {
+	stubExecManager
+	mu       sync.Mutex
+	attempts map[int64]int
+}
+
+func (s *scanStub) ExecBatchCASRows(b *BatchBuilder, dest ...interface{}) (bool, RowIterator, error) {
+	return false, nil, nil
+}
+
+func (s *scanStub) IterRows(c CqlBuilder, des ...interface{}) (RowIterator, error) {
+	_, vals, err := c.ToQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	start := vals[0].(int64)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[start]++
+	if start == math.MinInt64 && s.attempts[start] == 1 {
+		return nil, errors.New("timeout")
+	}
+	return NewSliceIterator([]string{"id"}, []interface{}{start}), nil
+}
+
+func TestTableScanner(t *testing.T) {
+	str, _, _ := (&TableScanner{Table: "t", PartitionKeys: []string{"a", "b"}, Columns: []string{"c"}}).rangeSelect(TokenRange{1, 2}).ToQuery()
+	if str != " SELECT c FROM t WHERE token(a,b)>? AND token(a,b)<=?" {
+		t.Logf("str %s", str)
+		t.FailNow()
+	}
+
+	stub := &scanStub{attempts: map[int64]int{}}
+	ranges := SplitTokenRing(8)
+	checkpoint := NewMemoryCheckpoint(ranges[7])
+
+	var mu sync.Mutex
+	rows := 0
+	var last ScanProgress
+	s := &TableScanner{
+		Mgr:           stub,
+		Table:         "t",
+		PartitionKeys: []string{"id"},
+		Columns:       []string{"id"},
+		Splits:        8,
+		Workers:       3,
+		Retries:       1,
+		Checkpoint:    checkpoint,
+		Progress:      func(p ScanProgress) { last = p },
+	}
+
+	err := s.Scan(context.Background(), func(ctx context.Context, r TokenRange, iter RowIterator) error {
+		var id int64
+		for iter.Scan(&id) {
+			mu.Lock()
+			rows++
+			mu.Unlock()
+		}
+		return nil
+	})
+
+	if err != nil || rows != 7 || stub.attempts[math.MinInt64] != 2 || len(checkpoint.Ranges()) != 8 {
+		t.Logf("err %v rows %d attempts %v", err, rows, stub.attempts)
+		t.FailNow()
+	}
+	if last.Completed != 7 || last.Skipped != 1 || last.Total != 8 {
+		t.Logf("progress %+v", last)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,560 @@
+package cqlbuilder
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	cql "github.com/gocql/gocql"
+)
+
+// The column of the table schema.
+type ColumnSchema struct {
+	Name   string
+	Type   CqlType
+	Static bool
+}
+
+// The schema of the table. The builders bound to it by SetSchema validate
+// the statement in ToQuery: the columns exist, the WHERE restricts the full
+// partition key(or ALLOW FILTERING for select), IF doesn't touch the primary
+// key columns, and the Go values are compatible with the column types.
+// The names are as Cassandra stores them, the columns of the builders are
+// matched by the CQL rules: the unquoted name is case insensitive.
+type TableSchema struct {
+	// The keyspace, empty if unknown.
+	Keyspace       string
+	Name           string
+	Columns        []ColumnSchema
+	PartitionKeys  []string
+	ClusteringKeys []string
+
+	// The table is not found in the registry.
+	missing bool
+	// The table name is registered in several keyspaces.
+	ambiguous bool
+}
+
+// Get the schema of the table created by the builder.
+func SchemaOf(ct *CreateTableBuilder) *TableSchema {
+	s := &TableSchema{
+		Name:           ct.table,
+		PartitionKeys:  canonicalNames(ct.partitionKeys),
+		ClusteringKeys: canonicalNames(ct.clusteringKeys),
+	}
+	for _, col := range ct.columns {
+		s.Columns = append(s.Columns, ColumnSchema{Name: canonicalName(col.name), Type: col.typ, Static: col.static})
+	}
+	if i := strings.Index(s.Name, "."); i >= 0 {
+		s.Keyspace, s.Name = s.Name[:i], s.Name[i+1:]
+	}
+	return s
+}
+
+// The ks.table name, or the table name if the keyspace is unknown.
+func (s *TableSchema) qualifiedName() string {
+	if len(s.Keyspace) == 0 {
+		return s.Name
+	}
+	return s.Keyspace + "." + s.Name
+}
+
+// Get the column by name, the name is the one used in CQL: `"Name"` or
+// userId(the column userid).
+func (s *TableSchema) Column(name string) (ColumnSchema, bool) {
+	name = canonicalName(name)
+	for _, col := range s.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return ColumnSchema{}, false
+}
+
+// Whether the column is partition or clustering key.
+func (s *TableSchema) IsPrimaryKey(name string) bool {
+	name = canonicalName(name)
+	for _, k := range s.PartitionKeys {
+		if k == name {
+			return true
+		}
+	}
+	for _, k := range s.ClusteringKeys {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// The registry of the table schemas.
+// Example:
+//
+//	reg := NewSchemaRegistry()
+//	reg.Register(SchemaOf(CreateTable("users")...))
+//	sel := reg.Select("users").AddColumn("nmae") // ToQuery fails, no column nmae
+type SchemaRegistry struct {
+	mu     sync.RWMutex
+	tables map[string]*TableSchema
+	// The table names registered in several keyspaces, only ks.table can
+	// be used for them.
+	ambiguous map[string]bool
+}
+
+// Create the schema registry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{tables: map[string]*TableSchema{}, ambiguous: map[string]bool{}}
+}
+
+// Register the table schemas, the one with the same name is replaced. The
+// table with keyspace is registered by both table and ks.table, unless the
+// table name is registered in another keyspace too: the bare name is
+// ambiguous then, the builders of it fail.
+func (r *SchemaRegistry) Register(schemas ...*TableSchema) *SchemaRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ambiguous == nil {
+		r.ambiguous = map[string]bool{}
+	}
+	for _, s := range schemas {
+		r.tables[s.qualifiedName()] = s
+		if prev := r.tables[s.Name]; prev != nil && prev.Keyspace != s.Keyspace && len(prev.Keyspace) > 0 && len(s.Keyspace) > 0 {
+			r.ambiguous[s.Name] = true
+		}
+		if r.ambiguous[s.Name] && len(s.Keyspace) > 0 {
+			delete(r.tables, s.Name)
+			continue
+		}
+		r.tables[s.Name] = s
+	}
+	return r
+}
+
+// Get the schema of the table, nil if not registered or the name is
+// ambiguous.
+func (r *SchemaRegistry) Table(name string) *TableSchema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tables[name]
+}
+
+// The schema to bind, the builder of the table not registered fails.
+func (r *SchemaRegistry) schemaFor(table string) *TableSchema {
+	if s := r.Table(table); s != nil {
+		return s
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &TableSchema{Name: table, missing: true, ambiguous: r.ambiguous[table]}
+}
+
+// Create the insert builder bound to the table schema.
+func (r *SchemaRegistry) Insert(t string) *InsertBuilder {
+	return Insert(t).SetSchema(r.schemaFor(t))
+}
+
+// Create the update builder bound to the table schema.
+func (r *SchemaRegistry) Update(t string) *UpdateBuilder {
+	return Update(t).SetSchema(r.schemaFor(t))
+}
+
+// Create the delete builder bound to the table schema.
+func (r *SchemaRegistry) Delete(t string) *DeleteBuilder {
+	return Delete(t).SetSchema(r.schemaFor(t))
+}
+
+// Create the select builder bound to the table schema.
+func (r *SchemaRegistry) Select(t string) *SelectBuilder {
+	return Select(t).SetSchema(r.schemaFor(t))
+}
+
+// The schema bound to the builder, or the one registered in reg. nil if
+// unknown.
+func schemaOfBuilder(c CqlBuilder, reg *SchemaRegistry, table string) *TableSchema {
+	var s *TableSchema
+	switch b := c.(type) {
+	case *SelectBuilder:
+		s = b.schema
+	case *InsertBuilder:
+		s = b.schema
+	case *UpdateBuilder:
+		s = b.schema
+	case *DeleteBuilder:
+		s = b.schema
+	}
+	if s != nil && !s.missing {
+		return s
+	}
+	if reg != nil {
+		return reg.Table(table)
+	}
+	return nil
+}
+
+func (s *TableSchema) violation(statement, reason string) error {
+	return &SchemaError{Statement: statement + " " + s.Name, Reason: reason}
+}
+
+// Check the column exists and the value is compatible, the value of the
+// element l[0] or addr.city is not checked.
+func (s *TableSchema) checkValue(statement, column string, value interface{}) error {
+	col, ok := s.Column(elementColumn(column))
+	if !ok {
+		return s.violation(statement, "unknown column "+column)
+	}
+	if elementColumn(column) != column {
+		return nil
+	}
+	if !compatibleValue(col.Type, value) {
+		return s.violation(statement, "value "+typeName(value)+" is not compatible with "+column+" "+col.Type.String())
+	}
+	return nil
+}
+
+// Check the column or the selector(function call on columns) exists.
+func (s *TableSchema) checkSelector(statement, column string) error {
+	if column == "*" {
+		return nil
+	}
+	if m := functionSelector.FindStringSubmatch(column); m != nil && !isQuoted(column) {
+		for _, arg := range splitArgs(m[2]) {
+			if err := s.checkSelector(statement, arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if _, ok := s.Column(elementColumn(column)); !ok {
+		return s.violation(statement, "unknown column "+column)
+	}
+	return nil
+}
+
+// Check the conditions, return the restricted columns by = or IN, and
+// whether the partition key is restricted by token().
+func (s *TableSchema) checkConditions(statement string, conds []conditionBuilder, isIf bool) (map[string]bool, bool, error) {
+	restricted := map[string]bool{}
+	byToken := false
+	for _, cond := range conds {
+		switch c := cond.(type) {
+		case *eqBuilder:
+			if err := s.checkValue(statement, c.column, c.value); err != nil {
+				return nil, false, err
+			}
+			restricted[canonicalName(c.column)] = true
+		case *inBuilder:
+			for _, v := range toSlice(c.values) {
+				if err := s.checkValue(statement, c.column, v); err != nil {
+					return nil, false, err
+				}
+			}
+			restricted[canonicalName(c.column)] = true
+		case *cmpBuilder:
+			if len(c.columns) == 1 && s.isToken(c.columns[0]) {
+				byToken = true
+				continue
+			}
+			for i, column := range c.columns {
+				if i >= len(c.values) {
+					break
+				}
+				if err := s.checkValue(statement, column, c.values[i]); err != nil {
+					return nil, false, err
+				}
+			}
+		case *notNullBuilder:
+			if err := s.checkSelector(statement, c.column); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	if isIf {
+		for _, cond := range conds {
+			var columns []string
+			switch c := cond.(type) {
+			case *eqBuilder:
+				columns = []string{c.column}
+			case *inBuilder:
+				columns = []string{c.column}
+			case *cmpBuilder:
+				columns = c.columns
+			}
+			for _, col := range columns {
+				if s.IsPrimaryKey(col) {
+					return nil, false, s.violation(statement, "IF can't have primary key column "+col)
+				}
+			}
+		}
+	}
+	return restricted, byToken, nil
+}
+
+// Whether it's token() of the full partition key.
+func (s *TableSchema) isToken(column string) bool {
+	m := functionSelector.FindStringSubmatch(column)
+	if m == nil || m[1] != "token" {
+		return false
+	}
+	args := splitArgs(m[2])
+	if len(args) != len(s.PartitionKeys) {
+		return false
+	}
+	for i, a := range args {
+		if canonicalName(a) != s.PartitionKeys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *TableSchema) checkPartitionKey(statement string, restricted map[string]bool, byToken bool) error {
+	if byToken {
+		return nil
+	}
+	for _, k := range s.PartitionKeys {
+		if !restricted[k] {
+			return s.violation(statement, "partition key "+k+" is not restricted")
+		}
+	}
+	return nil
+}
+
+func (s *TableSchema) missingReason() string {
+	if s.ambiguous {
+		return "table name is registered in several keyspaces, use ks.table"
+	}
+	return "table is not registered"
+}
+
+func (s *TableSchema) checkClusteringKey(statement string, restricted map[string]bool) error {
+	for _, k := range s.ClusteringKeys {
+		if !restricted[k] {
+			return s.violation(statement, "clustering key "+k+" is not restricted")
+		}
+	}
+	return nil
+}
+
+// Whether all the columns are static, false if no columns.
+func (s *TableSchema) onlyStatic(columns []string) bool {
+	for _, name := range columns {
+		if col, ok := s.Column(elementColumn(name)); !ok || !col.Static {
+			return false
+		}
+	}
+	return len(columns) > 0
+}
+
+// The restricted map is keyed by the canonical names.
+func restrictsAny(columns []string, restricted map[string]bool) bool {
+	for _, col := range columns {
+		if restricted[col] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TableSchema) validateInsert(c *InsertBuilder) error {
+	if s.missing {
+		return s.violation("INSERT", s.missingReason())
+	}
+	set := map[string]bool{}
+	for i, col := range c.colums {
+		if err := s.checkValue("INSERT", col, c.values[i]); err != nil {
+			return err
+		}
+		set[canonicalName(col)] = true
+	}
+	for _, k := range append(append([]string(nil), s.PartitionKeys...), s.ClusteringKeys...) {
+		if !set[k] {
+			return s.violation("INSERT", "primary key "+k+" is not set")
+		}
+	}
+	return nil
+}
+
+func (s *TableSchema) validateUpdate(c *UpdateBuilder) error {
+	if s.missing {
+		return s.violation("UPDATE", s.missingReason())
+	}
+	for i, col := range c.colums {
+		if err := s.checkValue("UPDATE", col, c.values[i]); err != nil {
+			return err
+		}
+		if s.IsPrimaryKey(col) {
+			return s.violation("UPDATE", "can't SET primary key column "+col)
+		}
+	}
+
+	restricted, _, err := s.checkConditions("UPDATE", c.whereConditions, false)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPartitionKey("UPDATE", restricted, false); err != nil {
+		return err
+	}
+	// The static columns belong to the partition.
+	if !s.onlyStatic(c.colums) {
+		if err := s.checkClusteringKey("UPDATE", restricted); err != nil {
+			return err
+		}
+	}
+
+	_, _, err = s.checkConditions("UPDATE", c.ifConditions, true)
+	return err
+}
+
+func (s *TableSchema) validateDelete(c *DeleteBuilder) error {
+	if s.missing {
+		return s.violation("DELETE", s.missingReason())
+	}
+	for _, col := range c.colums {
+		if err := s.checkSelector("DELETE", col); err != nil {
+			return err
+		}
+	}
+
+	restricted, _, err := s.checkConditions("DELETE", c.whereConditions, false)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPartitionKey("DELETE", restricted, false); err != nil {
+		return err
+	}
+	// The whole partition is deleted if no columns and no clustering key.
+	partition := len(c.colums) == 0 && !restrictsAny(s.ClusteringKeys, restricted)
+	if !partition && !s.onlyStatic(c.colums) {
+		if err := s.checkClusteringKey("DELETE", restricted); err != nil {
+			return err
+		}
+	}
+
+	_, _, err = s.checkConditions("DELETE", c.ifConditions, true)
+	return err
+}
+
+func (s *TableSchema) validateSelect(c *SelectBuilder) error {
+	if s.missing {
+		return s.violation("SELECT", s.missingReason())
+	}
+	for _, col := range c.colums {
+		if err := s.checkSelector("SELECT", col); err != nil {
+			return err
+		}
+	}
+	for _, col := range c.orderColumns {
+		if err := s.checkSelector("SELECT", col); err != nil {
+			return err
+		}
+	}
+
+	restricted, byToken, err := s.checkConditions("SELECT", c.whereConditions, false)
+	if err != nil {
+		return err
+	}
+	if c.allowFiltering {
+		return nil
+	}
+	return s.checkPartitionKey("SELECT", restricted, byToken)
+}
+
+// Split the args of the function call selector.
+func splitArgs(args string) []string {
+	ret := []string{}
+	for _, a := range strings.Split(args, comma) {
+		if a = strings.TrimSpace(a); len(a) > 0 {
+			ret = append(ret, a)
+		}
+	}
+	return ret
+}
+
+func typeName(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	uuidType      = reflect.TypeOf(cql.UUID{})
+	ipType        = reflect.TypeOf(net.IP{})
+	marshalerType = reflect.TypeOf((*cql.Marshaler)(nil)).Elem()
+	udtType       = reflect.TypeOf((*cql.UDTMarshaler)(nil)).Elem()
+)
+
+// Whether the Go value can be bound to the column type, it follows what
+// gocql marshals. The types hard to tell(decimal, duration, varint) accept
+// any value.
+func compatibleValue(t CqlType, v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	return compatibleType(t, reflect.TypeOf(v))
+}
+
+func compatibleType(t CqlType, rt reflect.Type) bool {
+	// The element of []interface{} or map[string]interface{} is not known.
+	if rt.Kind() == reflect.Interface || rt.Implements(marshalerType) {
+		return true
+	}
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+		if rt.Implements(marshalerType) {
+			return true
+		}
+	}
+
+	if t.UDT {
+		return rt.Kind() == reflect.Struct || rt.Kind() == reflect.Map || reflect.PtrTo(rt).Implements(udtType)
+	}
+
+	k := rt.Kind()
+	isInt := k >= reflect.Int && k <= reflect.Uint64
+	isBytes := k == reflect.Slice && rt.Elem().Kind() == reflect.Uint8
+
+	switch t.Name {
+	case "ascii", "text", "varchar":
+		return k == reflect.String || isBytes
+	case "tinyint", "smallint", "int", "bigint", "counter":
+		return isInt || k == reflect.String
+	case "float":
+		return k == reflect.Float32
+	case "double":
+		return k == reflect.Float64
+	case "boolean":
+		return k == reflect.Bool
+	case "blob":
+		return isBytes || k == reflect.String
+	case "uuid", "timeuuid":
+		return rt == uuidType || k == reflect.String || isBytes || k == reflect.Array && rt.Len() == 16
+	case "timestamp":
+		return rt == timeType || k == reflect.Int64 || k == reflect.Int
+	case "date":
+		return rt == timeType || isInt || k == reflect.String
+	case "time":
+		return rt == durationType || isInt
+	case "inet":
+		return rt == ipType || k == reflect.String
+	case "list", "set":
+		// gocql marshals map[T]struct{} as set.
+		if k == reflect.Map && t.Name == "set" {
+			return rt.Elem().Kind() == reflect.Struct && rt.Elem().NumField() == 0 && compatibleType(t.Params[0], rt.Key())
+		}
+		return (k == reflect.Slice || k == reflect.Array) && !isBytes && compatibleType(t.Params[0], rt.Elem())
+	case "map":
+		return k == reflect.Map && compatibleType(t.Params[0], rt.Key()) && compatibleType(t.Params[1], rt.Elem())
+	case "frozen":
+		return compatibleType(t.Params[0], rt)
+	case "tuple":
+		return k == reflect.Slice || k == reflect.Array || k == reflect.Struct
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package cqlbuilder
+
+import (
+	"testing"
+	"time"
+
+	cql "github.com/gocql/gocql"
+)
+
+func testSchemaRegistry() *SchemaRegistry {
+	return NewSchemaRegistry().Register(SchemaOf(CreateTable("events").
+		Column("tenant", Text).Column("day", Int).Column("ts", Timeuuid).
+		Column("kind", Text).Column("tags", SetOf(Text)).Column("attrs", MapOf(Text, Int)).
+		Column("at", Timestamp).Column("version", Bigint).Column("score", Float).Column("rate", Double).
+		StaticColumn("owner", Text).
+		PartitionKey("tenant", "day").ClusteringKey("ts")))
+}
+
+func TestSchemaValid(t *testing.T) {
+	reg := testSchemaRegistry()
+	cases := []CqlBuilder{
+		reg.Insert("events").SetValue("tenant", "t1").SetValue("day", 20200101).SetValue("ts", cql.TimeUUID()).
+			SetValue("tags", []string{"a"}).SetValue("attrs", map[string]int{"n": 1}).SetValue("at", time.Now()),
+		reg.Update("events").SetValue("kind", "click").SetValue("version", int64(2)).
+			Where(Eq("tenant", "t1")).Where(Eq("day", 1)).Where(Eq("ts", cql.TimeUUID())).If(Eq("version", 1)),
+		reg.Delete("events").DeleteColumn("tags").Where(Eq("tenant", "t1")).Where(In("day", []int{1, 2})).Where(Eq("ts", cql.TimeUUID())),
+		reg.Delete("events").Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Update("events").SetValue("attrs['n']", 1).Where(Eq("tenant", "t1")).Where(Eq("day", 1)).Where(Eq("ts", cql.TimeUUID())),
+		reg.Delete("events").DeleteColumn("attrs['n']").Where(Eq("tenant", "t1")).Where(Eq("day", 1)).Where(Eq("ts", cql.TimeUUID())),
+		reg.Delete("events").DeleteColumn("owner").Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Update("events").SetValue("owner", "u1").Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Update("events").SetValue("score", float32(1.5)).SetValue("rate", 0.5).
+			Where(Eq("tenant", "t1")).Where(Eq("day", 1)).Where(Eq("ts", cql.TimeUUID())),
+		reg.Select("events").AddColumns("kind", "writetime(kind)").Where(Eq("tenant", "t1")).Where(Eq("day", 1)).OrderBy("ts", true),
+		reg.Select("events").AddColumn("kind").Where(Eq("kind", "click")).SetAllowFiltering(true),
+		reg.Select("events").AddColumn("kind").Where(Gt(Token("tenant", "day"), 0)),
+		reg.Select("events").AddColumn("kind").Where(In("tenant", []interface{}{"t1", "t2"})).Where(Eq("day", 1)),
+	}
+
+	for i, c := range cases {
+		if _, _, err := c.ToQuery(); err != nil {
+			t.Logf("case %d: err %v", i, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSchemaViolation(t *testing.T) {
+	reg := testSchemaRegistry()
+	cases := []CqlBuilder{
+		reg.Insert("evnets").SetValue("tenant", "t1"),
+		reg.Insert("events").SetValue("tenant", "t1").SetValue("day", 1).SetValue("ts", cql.TimeUUID()).SetValue("knid", "x"),
+		reg.Insert("events").SetValue("tenant", "t1").SetValue("day", 1),
+		reg.Insert("events").SetValue("tenant", "t1").SetValue("day", "x").SetValue("ts", true),
+		reg.Insert("events").SetValue("tenant", "t1").SetValue("day", 1).SetValue("ts", cql.TimeUUID()).SetValue("tags", map[string]int{}),
+		reg.Insert("events").SetValue("tenant", "t1").SetValue("day", 1).SetValue("ts", cql.TimeUUID()).SetValue("attrs", map[string]bool{}),
+		reg.Update("events").SetValue("kind", "x").Where(Eq("tenant", "t1")),
+		reg.Update("events").SetValue("day", 2).Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Update("events").SetValue("kind", "x").Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Update("events").SetValue("kind", "x").SetValue("owner", "u1").Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Update("events").SetValue("score", 1.5).Where(Eq("tenant", "t1")).Where(Eq("day", 1)).Where(Eq("ts", cql.TimeUUID())),
+		reg.Update("events").SetValue("rate", float32(0.5)).Where(Eq("tenant", "t1")).Where(Eq("day", 1)).Where(Eq("ts", cql.TimeUUID())),
+		reg.Delete("events").DeleteColumn("tags").Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Update("events").SetValue("atrs['n']", 1).Where(Eq("tenant", "t1")).Where(Eq("day", 1)).Where(Eq("ts", cql.TimeUUID())),
+		reg.Update("events").SetValue("kind", "x").Where(Eq("tenant", "t1")).Where(Eq("day", 1)).If(Eq("ts", cql.TimeUUID())),
+		reg.Delete("events").Where(Eq("tenant", "t1")).Where(Eq("day", 1)).If(Gt("day", 1)),
+		reg.Select("events").AddColumn("kind").Where(Eq("kind", "click")),
+		reg.Select("events").AddColumn("kidn").Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Select("events").AddColumn("ttl(kidn)").Where(Eq("tenant", "t1")).Where(Eq("day", 1)),
+		reg.Select("events").AddColumn("kind").Where(Eq("tenant", 1)).Where(Eq("day", 1)),
+		reg.Select("events").AddColumn("kind").Where(In("tenant", []int{1})).Where(Eq("day", 1)),
+		reg.Select("events").AddColumn("kind").Where(Gt(Token("tenant"), 0)),
+	}
+
+	for i, c := range cases {
+		_, _, err := c.ToQuery()
+		if _, ok := err.(*SchemaError); !ok {
+			t.Logf("case %d: SchemaError expected, got %v", i, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSchemaRegistryKeyspaces(t *testing.T) {
+	users := func(ks string) *TableSchema {
+		return SchemaOf(CreateTable(ks+".users").Column("id", Text).Column("name", Text).PartitionKey("id"))
+	}
+	reg := NewSchemaRegistry().Register(users("app"))
+	if reg.Table("users") == nil || reg.Table("app.users") == nil {
+		t.Logf("users not registered")
+		t.FailNow()
+	}
+
+	// The same table name in another keyspace makes the bare name ambiguous.
+	reg.Register(users("audit"))
+	if reg.Table("users") != nil || reg.Table("app.users").Keyspace != "app" || reg.Table("audit.users").Keyspace != "audit" {
+		t.Logf("bare name should be ambiguous")
+		t.FailNow()
+	}
+
+	_, _, err := reg.Select("users").AddColumn("name").Where(Eq("id", "u1")).ToQuery()
+	if _, ok := err.(*SchemaError); !ok {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	if _, _, err := reg.Select("audit.users").AddColumn("name").Where(Eq("id", "u1")).ToQuery(); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+}
+
+func TestSchemaColumnNames(t *testing.T) {
+	ks, err := ParseSchema(`CREATE TABLE app.users (orgId text, id int, "Name" text, PRIMARY KEY (orgId, id));`)
+	if err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	built := SchemaOf(CreateTable("app.users").Column("orgId", Text).Column("id", Int).Column(`"Name"`, Text).
+		PartitionKey("orgId").ClusteringKey("id"))
+
+	for _, s := range []*TableSchema{ks.Table("users"), built} {
+		if s.PartitionKeys[0] != "orgid" || s.Columns[2].Name != "Name" {
+			t.Logf("schema %+v", s)
+			t.FailNow()
+		}
+
+		// The unquoted name is case insensitive, the quoted one is not.
+		reg := NewSchemaRegistry().Register(s)
+		valid := []CqlBuilder{
+			reg.Select("users").AddColumn("id").Where(Eq("orgId", "o1")),
+			reg.Select("users").AddColumn(`"Name"`).Where(Eq("ORGID", "o1")).Where(Eq("id", 1)),
+			reg.Insert("users").SetValue("orgId", "o1").SetValue("Id", 1).SetValue(`"Name"`, "n"),
+			reg.Update("users").SetValue(`"Name"`, "n").Where(Eq("orgid", "o1")).Where(Eq("id", 1)),
+			reg.Select("users").AddColumn("id").Where(Gt(Token("orgId"), 0)),
+		}
+		for i, c := range valid {
+			if _, _, err := c.ToQuery(); err != nil {
+				t.Logf("case %d: err %v", i, err)
+				t.FailNow()
+			}
+		}
+
+		invalid := []CqlBuilder{
+			reg.Select("users").AddColumn("Name").Where(Eq("orgId", "o1")),
+			reg.Select("users").AddColumn("id").Where(Eq(`"orgId"`, "o1")),
+			reg.Update("users").SetValue(`"Name"`, "n").Where(Eq("orgId", "o1")).Where(Eq("id", 1)).If(Eq("ID", 1)),
+		}
+		for i, c := range invalid {
+			if _, _, err := c.ToQuery(); err == nil {
+				t.Logf("case %d: err expected", i)
+				t.FailNow()
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cqlbuilder
+
+import (
+	"sort"
+)
+
+// Load the tables and types of the keyspace from system_schema, it needs
+// Cassandra 3.0 or later.
+// Example:
+//
+//	ks, err := LoadSystemSchema(em, "app")
+//	reg := NewSchemaRegistry().Register(ks.Tables...)
+func LoadSystemSchema(mgr ExecManagerV2, keyspace string) (*KeyspaceSchema, error) {
+	tables, err := loadSystemTables(mgr, keyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := loadSystemTypes(mgr, keyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyspaceSchema{Tables: tables, Types: types}, nil
+}
+
+// The key column and its position in the key.
+type keyPosition struct {
+	name     string
+	position int
+}
+
+func loadSystemTables(mgr ExecManagerV2, keyspace string) ([]*TableSchema, error) {
+	sel := Select("system_schema.columns").
+		AddColumns("table_name", "column_name", "kind", "position", "type").
+		Where(Eq("keyspace_name", keyspace))
+	iter, err := mgr.IterRows(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*TableSchema
+	byName := map[string]*TableSchema{}
+	pks := map[string][]keyPosition{}
+	cks := map[string][]keyPosition{}
+
+	var table, column, kind, typ string
+	var position int
+	for iter.Scan(&table, &column, &kind, &position, &typ) {
+		t := byName[table]
+		if t == nil {
+			t = &TableSchema{Keyspace: keyspace, Name: table}
+			byName[table] = t
+			tables = append(tables, t)
+		}
+
+		ct, err := ParseType(typ)
+		if err != nil {
+			iter.Close()
+			return nil, err
+		}
+		t.Columns = append(t.Columns, ColumnSchema{Name: column, Type: ct, Static: kind == "static"})
+
+		switch kind {
+		case "partition_key":
+			pks[table] = append(pks[table], keyPosition{column, position})
+		case "clustering":
+			cks[table] = append(cks[table], keyPosition{column, position})
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range tables {
+		t.PartitionKeys = sortedKeys(pks[t.Name])
+		t.ClusteringKeys = sortedKeys(cks[t.Name])
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables, nil
+}
+
+func sortedKeys(keys []keyPosition) []string {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].position < keys[j].position })
+	ret := make([]string, len(keys))
+	for i, k := range keys {
+		ret[i] = k.name
+	}
+	return ret
+}
+
+func loadSystemTypes(mgr ExecManagerV2, keyspace string) ([]*TypeSchema, error) {
+	sel := Select("system_schema.types").
+		AddColumns("type_name", "field_names", "field_types").
+		Where(Eq("keyspace_name", keyspace))
+	iter, err := mgr.IterRows(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	var types []*TypeSchema
+	var name string
+	var fieldNames, fieldTypes []string
+	for iter.Scan(&name, &fieldNames, &fieldTypes) {
+		t := &TypeSchema{Keyspace: keyspace, Name: name}
+		for i, f := range fieldNames {
+			if i >= len(fieldTypes) {
+				break
+			}
+			ft, err := ParseType(fieldTypes[i])
+			if err != nil {
+				iter.Close()
+				return nil, err
+			}
+			t.Fields = append(t.Fields, ColumnSchema{Name: f, Type: ft})
+		}
+		types = append(types, t)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types, nil
+}
@@ -0,0 +1,501 @@
+package cqlbuilder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// The schema of the user defined type.
+type TypeSchema struct {
+	Keyspace string
+	Name     string
+	Fields   []ColumnSchema
+}
+
+// The tables and types loaded from system_schema or the CQL script.
+type KeyspaceSchema struct {
+	Tables []*TableSchema
+	Types  []*TypeSchema
+}
+
+// Get the table by name, ks.table is supported.
+func (k *KeyspaceSchema) Table(name string) *TableSchema {
+	for _, t := range k.Tables {
+		if t.Name == name || t.qualifiedName() == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Get the type by name, ks.type is supported.
+func (k *KeyspaceSchema) Type(name string) *TypeSchema {
+	for _, t := range k.Types {
+		if t.Name == name || len(t.Keyspace) > 0 && t.Keyspace+"."+t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// The native types by name.
+var nativeTypes = map[string]CqlType{}
+
+func init() {
+	for _, t := range []CqlType{Ascii, Bigint, Blob, Boolean, Counter, Date, Decimal, Double, Duration,
+		Float, Inet, Int, Smallint, Text, Time, Timestamp, Timeuuid, Tinyint, Uuid, Varchar, Varint} {
+		nativeTypes[t.Name] = t
+	}
+}
+
+// Parse the CQL type, sth like frozen<map<text, list<int>>>. The name not
+// native is the user defined type.
+func ParseType(s string) (CqlType, error) {
+	p, err := newCqlParser(s)
+	if err != nil {
+		return CqlType{}, err
+	}
+
+	t, err := p.parseType()
+	if err != nil {
+		return CqlType{}, err
+	}
+	if !p.eof() {
+		return CqlType{}, p.errorf("unexpected %q after type", p.peek().text)
+	}
+	return t, nil
+}
+
+// Load the tables and types of the CQL script file.
+func LoadSchemaFile(path string) (*KeyspaceSchema, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSchema(string(data))
+}
+
+// Parse the CREATE TABLE and CREATE TYPE statements of the CQL script, the
+// other statements are skipped. USE sets the keyspace of the later ones.
+func ParseSchema(script string) (*KeyspaceSchema, error) {
+	p, err := newCqlParser(script)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeyspaceSchema{}
+	keyspace := ""
+	for !p.eof() {
+		switch {
+		case p.accept(";"):
+		case p.accept("use"):
+			name, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			keyspace = name
+		case p.accept("create"):
+			switch {
+			case p.accept("table"), p.accept("columnfamily"):
+				t, err := p.parseCreateTable(keyspace)
+				if err != nil {
+					return nil, err
+				}
+				ks.Tables = append(ks.Tables, t)
+				continue
+			case p.accept("type"):
+				t, err := p.parseCreateType(keyspace)
+				if err != nil {
+					return nil, err
+				}
+				ks.Types = append(ks.Types, t)
+				continue
+			}
+			p.skipStatement()
+		default:
+			p.skipStatement()
+		}
+	}
+	return ks, nil
+}
+
+// The token of the CQL script.
+type cqlToken struct {
+	// i: identifier(lower cased), q: quoted identifier, s: string,
+	// n: number or other word, p: punctuation.
+	kind byte
+	text string
+	line int
+}
+
+type cqlParser struct {
+	toks []cqlToken
+	pos  int
+}
+
+func newCqlParser(s string) (*cqlParser, error) {
+	toks, err := tokenizeCql(s)
+	if err != nil {
+		return nil, err
+	}
+	return &cqlParser{toks: toks}, nil
+}
+
+// Split the script into tokens, the comments are dropped.
+func tokenizeCql(s string) ([]cqlToken, error) {
+	var toks []cqlToken
+	line := 1
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(s[i:], "--") || strings.HasPrefix(s[i:], "//"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("cqlbuilder: line %d: unterminated comment", line)
+			}
+			line += strings.Count(s[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"' || c == '\'':
+			var buf strings.Builder
+			start := line
+			j := i + 1
+			for ; j < len(s); j++ {
+				if s[j] == c {
+					if j+1 < len(s) && s[j+1] == c {
+						buf.WriteByte(c)
+						j++
+						continue
+					}
+					break
+				}
+				if s[j] == '\n' {
+					line++
+				}
+				buf.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("cqlbuilder: line %d: unterminated quote", start)
+			}
+			kind := byte('q')
+			if c == '\'' {
+				kind = 's'
+			}
+			toks = append(toks, cqlToken{kind: kind, text: buf.String(), line: start})
+			i = j + 1
+		case isWordByte(c):
+			j := i
+			for j < len(s) && (isWordByte(s[j]) || s[j] == '.' && j > i && isDigits(s[i:j])) {
+				j++
+			}
+			word := s[i:j]
+			kind := byte('i')
+			if c >= '0' && c <= '9' {
+				kind = 'n'
+			}
+			toks = append(toks, cqlToken{kind: kind, text: strings.ToLower(word), line: line})
+			i = j
+		default:
+			toks = append(toks, cqlToken{kind: 'p', text: string(c), line: line})
+			i++
+		}
+	}
+	return toks, nil
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func (p *cqlParser) eof() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *cqlParser) peek() cqlToken {
+	if p.eof() {
+		return cqlToken{kind: 'e', text: "EOF"}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *cqlParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.eof() && len(p.toks) > 0 {
+		line = p.toks[len(p.toks)-1].line
+	} else if !p.eof() {
+		line = p.peek().line
+	}
+	return fmt.Errorf("cqlbuilder: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// Consume the keyword(identifier) or punctuation if it's next.
+func (p *cqlParser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == 'i' || t.kind == 'p') && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *cqlParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expect %q, got %q", text, p.peek().text)
+	}
+	return nil
+}
+
+// Skip to the end of the statement.
+func (p *cqlParser) skipStatement() {
+	for !p.eof() && !p.accept(";") {
+		p.pos++
+	}
+}
+
+func (p *cqlParser) parseIdent() (string, error) {
+	t := p.peek()
+	if t.kind != 'i' && t.kind != 'q' {
+		return "", p.errorf("expect identifier, got %q", t.text)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// Parse [ks.]name, the keyspace is the default if not qualified.
+func (p *cqlParser) parseQualifiedName(keyspace string) (string, string, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		return "", "", err
+	}
+	if p.accept(".") {
+		keyspace = name
+		if name, err = p.parseIdent(); err != nil {
+			return "", "", err
+		}
+	}
+	return keyspace, name, nil
+}
+
+func (p *cqlParser) acceptIfNotExists() error {
+	if p.accept("if") {
+		if err := p.expect("not"); err != nil {
+			return err
+		}
+		return p.expect("exists")
+	}
+	return nil
+}
+
+// Parse the type and validate it, the nested types are validated as a
+// whole since the ones inside frozen are frozen implicitly.
+func (p *cqlParser) parseType() (CqlType, error) {
+	t, err := p.parseTypeExpr()
+	if err != nil {
+		return CqlType{}, err
+	}
+	return t, t.validate()
+}
+
+func (p *cqlParser) parseTypeExpr() (CqlType, error) {
+	t := p.peek()
+	if t.kind != 'i' && t.kind != 'q' {
+		return CqlType{}, p.errorf("expect type, got %q", t.text)
+	}
+
+	switch {
+	case t.kind == 'i' && (t.text == "frozen" || t.text == "list" || t.text == "set" || t.text == "map" || t.text == "tuple"):
+		p.pos++
+		if err := p.expect("<"); err != nil {
+			return CqlType{}, err
+		}
+		ret := CqlType{Name: t.text}
+		for {
+			param, err := p.parseTypeExpr()
+			if err != nil {
+				return CqlType{}, err
+			}
+			ret.Params = append(ret.Params, param)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(">"); err != nil {
+			return CqlType{}, err
+		}
+		return ret, nil
+	}
+
+	if native, ok := nativeTypes[t.text]; ok && t.kind == 'i' {
+		p.pos++
+		return native, nil
+	}
+
+	ks, name, err := p.parseQualifiedName("")
+	if len(ks) > 0 {
+		name = ks + "." + name
+	}
+	return UDT(name), err
+}
+
+// Parse CREATE TABLE after the keywords.
+func (p *cqlParser) parseCreateTable(keyspace string) (*TableSchema, error) {
+	if err := p.acceptIfNotExists(); err != nil {
+		return nil, err
+	}
+
+	ks, name, err := p.parseQualifiedName(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	t := &TableSchema{Keyspace: ks, Name: name}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		if p.accept("primary") {
+			if err := p.expect("key"); err != nil {
+				return nil, err
+			}
+			if err := p.parsePrimaryKey(t); err != nil {
+				return nil, err
+			}
+		} else {
+			col, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			typ, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			cs := ColumnSchema{Name: col, Type: typ}
+			for {
+				if p.accept("static") {
+					cs.Static = true
+				} else if p.accept("primary") {
+					if err := p.expect("key"); err != nil {
+						return nil, err
+					}
+					t.PartitionKeys = []string{col}
+				} else {
+					break
+				}
+			}
+			t.Columns = append(t.Columns, cs)
+		}
+
+		if p.accept(")") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(t.PartitionKeys) == 0 {
+		return nil, p.errorf("table %s has no primary key", name)
+	}
+	for _, k := range append(append([]string(nil), t.PartitionKeys...), t.ClusteringKeys...) {
+		if _, ok := t.Column(k); !ok {
+			return nil, p.errorf("key column %s of table %s is not defined", k, name)
+		}
+	}
+
+	p.skipStatement()
+	return t, nil
+}
+
+// Parse (pk, ck...) or ((pk1, pk2), ck...).
+func (p *cqlParser) parsePrimaryKey(t *TableSchema) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+
+	if p.accept("(") {
+		for {
+			k, err := p.parseIdent()
+			if err != nil {
+				return err
+			}
+			t.PartitionKeys = append(t.PartitionKeys, k)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	} else {
+		k, err := p.parseIdent()
+		if err != nil {
+			return err
+		}
+		t.PartitionKeys = []string{k}
+	}
+
+	for p.accept(",") {
+		k, err := p.parseIdent()
+		if err != nil {
+			return err
+		}
+		t.ClusteringKeys = append(t.ClusteringKeys, k)
+	}
+	return p.expect(")")
+}
+
+// Parse CREATE TYPE after the keywords.
+func (p *cqlParser) parseCreateType(keyspace string) (*TypeSchema, error) {
+	if err := p.acceptIfNotExists(); err != nil {
+		return nil, err
+	}
+
+	ks, name, err := p.parseQualifiedName(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	t := &TypeSchema{Keyspace: ks, Name: name}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		field, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		t.Fields = append(t.Fields, ColumnSchema{Name: field, Type: typ})
+
+		if p.accept(")") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+
+	p.skipStatement()
+	return t, nil
+}
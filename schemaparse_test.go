@@ -0,0 +1,144 @@
+package cqlbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseType(t *testing.T) {
+	cases := map[string]CqlType{
+		"int":                               Int,
+		"frozen<map<text, list<int>>>":      Frozen(MapOf(Text, ListOf(Int))),
+		"map<text,frozen<list<int>>>":       MapOf(Text, Frozen(ListOf(Int))),
+		"tuple<int, text, frozen<address>>": TupleOf(Int, Text, Frozen(UDT("address"))),
+		"set<frozen<ks.\"Point\">>":         SetOf(Frozen(UDT("ks.Point"))),
+		"TIMEUUID":                          Timeuuid,
+	}
+
+	for s, expected := range cases {
+		typ, err := ParseType(s)
+		if err != nil || !reflect.DeepEqual(typ, expected) {
+			t.Logf("type %s parsed %#v err %v", s, typ, err)
+			t.FailNow()
+		}
+	}
+
+	for _, s := range []string{"", "list<int", "map<int>", "list<list<int>>", "int int", "<int>"} {
+		if _, err := ParseType(s); err == nil {
+			t.Logf("err expected for %q", s)
+			t.FailNow()
+		}
+	}
+}
+
+func TestLoadSchemaFile(t *testing.T) {
+	ks, err := LoadSchemaFile("testdata/schema.cql")
+	if err != nil || len(ks.Tables) != 2 || len(ks.Types) != 1 {
+		t.Logf("schema %+v err %v", ks, err)
+		t.FailNow()
+	}
+
+	users := ks.Table("app.users")
+	if users == nil || users.Keyspace != "app" || len(users.Columns) != 8 ||
+		!reflect.DeepEqual(users.PartitionKeys, []string{"org_id"}) || !reflect.DeepEqual(users.ClusteringKeys, []string{"id"}) {
+		t.Logf("users %+v", users)
+		t.FailNow()
+	}
+	if col, ok := users.Column(`"displayName"`); !ok || !reflect.DeepEqual(col.Type, Text) {
+		t.Logf("displayName %+v", col)
+		t.FailNow()
+	}
+	if col, _ := users.Column("owner"); !col.Static {
+		t.Logf("owner %+v", col)
+		t.FailNow()
+	}
+	if col, _ := users.Column("addr"); !reflect.DeepEqual(col.Type, Frozen(UDT("address"))) {
+		t.Logf("addr %+v", col)
+		t.FailNow()
+	}
+
+	events := ks.Table("audit.events")
+	if events == nil || !reflect.DeepEqual(events.PartitionKeys, []string{"id"}) || len(events.ClusteringKeys) != 0 {
+		t.Logf("events %+v", events)
+		t.FailNow()
+	}
+
+	addr := ks.Type("app.address")
+	if addr == nil || len(addr.Fields) != 3 || addr.Fields[2].Name != "Geo" {
+		t.Logf("address %+v", addr)
+		t.FailNow()
+	}
+
+	// the builders are validated against the checked-in schema
+	reg := NewSchemaRegistry().Register(ks.Tables...)
+	if _, _, err := reg.Select("users").AddColumn(`"displayName"`).Where(Eq("org_id", "o1")).ToQuery(); err != nil {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+	// the quoted column is case sensitive
+	if _, _, err := reg.Select("users").AddColumn("displayName").Where(Eq("org_id", "o1")).ToQuery(); err == nil {
+		t.Logf("err expected")
+		t.FailNow()
+	}
+	if _, _, err := reg.Select("app.users").AddColumn("display_name").Where(Eq("org_id", "o1")).ToQuery(); err == nil {
+		t.Logf("err expected")
+		t.FailNow()
+	}
+}
+
+func TestParseSchemaError(t *testing.T) {
+	for _, s := range []string{
+		"CREATE TABLE t (id int)",
+		"CREATE TABLE t (id int, PRIMARY KEY (other))",
+		"CREATE TABLE t (id list<int PRIMARY KEY)",
+		"CREATE TYPE t (a int",
+		"/* not closed",
+		"CREATE TABLE t (id text PRIMARY KEY, name 'text)",
+	} {
+		if _, err := ParseSchema(s); err == nil {
+			t.Logf("err expected for %q", s)
+			t.FailNow()
+		}
+	}
+}
+
+func TestLoadSystemSchema(t *testing.T) {
+	fake := NewFakeExecManager().
+		DefineTable("system_schema.columns", "keyspace_name", "table_name", "column_name").
+		DefineTable("system_schema.types", "keyspace_name", "type_name")
+	for _, c := range [][]interface{}{
+		{"users", "id", "clustering", 0, "uuid"},
+		{"users", "name", "regular", -1, "text"},
+		{"users", "org_id", "partition_key", 1, "text"},
+		{"users", "region", "partition_key", 0, "text"},
+		{"users", "addr", "regular", -1, "frozen<address>"},
+		{"users", "owner", "static", -1, "text"},
+	} {
+		fake.Exec(Insert("system_schema.columns").SetValue("keyspace_name", "app").SetValue("table_name", c[0]).
+			SetValue("column_name", c[1]).SetValue("kind", c[2]).SetValue("position", c[3]).SetValue("type", c[4]))
+	}
+	fake.Exec(Insert("system_schema.columns").SetValue("keyspace_name", "other").SetValue("table_name", "t").
+		SetValue("column_name", "id").SetValue("kind", "partition_key").SetValue("position", 0).SetValue("type", "int"))
+	fake.Exec(Insert("system_schema.types").SetValue("keyspace_name", "app").SetValue("type_name", "address").
+		SetValue("field_names", []string{"street", "zip"}).SetValue("field_types", []string{"text", "int"}))
+
+	ks, err := LoadSystemSchema(fake, "app")
+	if err != nil || len(ks.Tables) != 1 || len(ks.Types) != 1 {
+		t.Logf("schema %+v err %v", ks, err)
+		t.FailNow()
+	}
+
+	users := ks.Table("app.users")
+	if !reflect.DeepEqual(users.PartitionKeys, []string{"region", "org_id"}) || !reflect.DeepEqual(users.ClusteringKeys, []string{"id"}) || len(users.Columns) != 6 {
+		t.Logf("users %+v", users)
+		t.FailNow()
+	}
+	if col, _ := users.Column("owner"); !col.Static {
+		t.Logf("owner %+v", col)
+		t.FailNow()
+	}
+	if addr := ks.Type("address"); len(addr.Fields) != 2 || !reflect.DeepEqual(addr.Fields[1].Type, Int) {
+		t.Logf("address %+v", addr)
+		t.FailNow()
+	}
+}
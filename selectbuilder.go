@@ -13,6 +13,12 @@ type SelectBuilder struct {
 	limitNumber     int
 	table           string
 	allowFiltering  bool
+	orderColumns    []string
+	orderDesc       []bool
+	options         *QueryOptions
+	schema          *TableSchema
+	// The error happened when building, returned by ToQuery.
+	err error
 }
 
 // Set a value
@@ -29,6 +35,35 @@ func (c *SelectBuilder) AddColumns(cols ...string) *SelectBuilder {
 	return c
 }
 
+// Add the columns of the `cql` tagged struct, in the order of the fields.
+func (c *SelectBuilder) AddStructColumns(v interface{}) *SelectBuilder {
+	cols, err := StructColumns(v)
+	if err != nil {
+		c.err = err
+		return c
+	}
+	return c.AddColumns(cols...)
+}
+
+// Copy the builder, the copy can be changed without touching c.
+func (c *SelectBuilder) clone() *SelectBuilder {
+	ret := *c
+	ret.colums = append([]string(nil), c.colums...)
+	ret.whereConditions = append([]conditionBuilder(nil), c.whereConditions...)
+	ret.orderColumns = append([]string(nil), c.orderColumns...)
+	ret.orderDesc = append([]bool(nil), c.orderDesc...)
+	return &ret
+}
+
+// Return the copy of the builder with the columns of the struct if it has
+// no columns, the builder of the caller is not changed.
+func (c *SelectBuilder) withStructColumns(v interface{}) *SelectBuilder {
+	if len(c.colums) > 0 {
+		return c
+	}
+	return c.clone().AddStructColumns(v)
+}
+
 // Set the where condition.
 func (c *SelectBuilder) Where(condition conditionBuilder) *SelectBuilder {
 	c.whereConditions = append(c.whereConditions, condition)
@@ -42,6 +77,13 @@ func (c *SelectBuilder) SetLimit(n int) *SelectBuilder {
 	return c
 }
 
+// Add the ORDER BY clustering column.
+func (c *SelectBuilder) OrderBy(column string, desc bool) *SelectBuilder {
+	c.orderColumns = append(c.orderColumns, column)
+	c.orderDesc = append(c.orderDesc, desc)
+	return c
+}
+
 //Set allow filtering
 func (c *SelectBuilder) SetAllowFiltering(allow bool) *SelectBuilder {
 	c.allowFiltering = allow
@@ -50,6 +92,10 @@ func (c *SelectBuilder) SetAllowFiltering(allow bool) *SelectBuilder {
 
 // Validate
 func (c *SelectBuilder) validate() error {
+	if c.err != nil {
+		return c.err
+	}
+
 	if len(c.table) == 0 {
 		return errEmptyTable
 	}
@@ -67,12 +113,23 @@ func (c *SelectBuilder) validate() error {
 
 // Build the select query statement string and values.
 // Example:
-//  SELECT col1,col2,Col3 FROM test WHERE Col4=? AND Col5=?
+//  SELECT col1,col2,"select" FROM test WHERE "Col 4"=? AND col5=?
 func (c *SelectBuilder) ToQuery() (string, []interface{}, error) {
 	if err := c.validate(); err != nil {
 		return "", nil, err
 	}
 
+	if c.schema != nil {
+		if err := c.schema.validateSelect(c); err != nil {
+			return "", nil, err
+		}
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+
 	var buf bytes.Buffer
 
 	buf.WriteString(selectKW)
@@ -86,16 +143,44 @@ func (c *SelectBuilder) ToQuery() (string, []interface{}, error) {
 			buf.WriteString(comma)
 		}
 
-		buf.WriteString(c.colums[i])
+		col, err := quoteColumn(c.colums[i])
+		if err != nil {
+			return "", nil, err
+		}
+
+		buf.WriteString(col)
 	}
 
 	buf.WriteString(from)
-	buf.WriteString(c.table)
+	buf.WriteString(table)
 
-	condition, conditionValues := buildCondition(c.whereConditions)
+	condition, conditionValues, err := buildCondition(c.whereConditions)
+	if err != nil {
+		return "", nil, err
+	}
 	buf.WriteString(where)
 	buf.WriteString(condition)
 
+	for i, col := range c.orderColumns {
+		if i == 0 {
+			buf.WriteString(orderBy)
+		} else {
+			buf.WriteString(comma)
+		}
+
+		q, err := quoteColumn(col)
+		if err != nil {
+			return "", nil, err
+		}
+
+		buf.WriteString(q)
+		if c.orderDesc[i] {
+			buf.WriteString(desc)
+		} else {
+			buf.WriteString(asc)
+		}
+	}
+
 	if c.limitNumber > 0 {
 		buf.WriteString(limit)
 		buf.WriteString(strconv.Itoa(c.limitNumber))
@@ -107,3 +192,28 @@ func (c *SelectBuilder) ToQuery() (string, []interface{}, error) {
 
 	return buf.String(), conditionValues, nil
 }
+
+// Attach the query options(consistency, page size ...) to the statement.
+func (c *SelectBuilder) SetOptions(o *QueryOptions) *SelectBuilder {
+	c.options = o
+	return c
+}
+
+// Bind the statement to the table schema, ToQuery validates the columns,
+// keys and value types by it.
+func (c *SelectBuilder) SetSchema(s *TableSchema) *SelectBuilder {
+	c.schema = s
+	return c
+}
+
+func (c *SelectBuilder) queryOptions() *QueryOptions {
+	return c.options
+}
+
+func (c *SelectBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *SelectBuilder) statementKind() StatementKind {
+	return KindSelect
+}
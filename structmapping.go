@@ -0,0 +1,223 @@
+package cqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The tag name of the struct field, the format is `cql:"column,option..."`.
+// The options are:
+//
+//	pk        partition key column, used in WHERE of update.
+//	ck        clustering key column, used in WHERE of update.
+//	omitempty skip the field if it has zero value.
+//	ttl       the field is the TTL seconds of the statement, not a column;
+//	          time.Duration is rounded up to the seconds.
+//
+// Only the tagged fields are mapped, `cql:"-"` is ignored. The fields of
+// the embedded struct are mapped as the fields of the outer struct.
+const structTag = "cql"
+
+var (
+	errNotStruct    = errors.New("cqlbuilder: need a struct or a pointer to struct")
+	errNoKeyColumns = errors.New("cqlbuilder: struct has no pk/ck field for WHERE")
+)
+
+// The reflected metadata of the struct field.
+type fieldInfo struct {
+	column    string
+	index     []int
+	pk        bool
+	ck        bool
+	omitempty bool
+}
+
+// The reflected metadata of the struct.
+type structInfo struct {
+	fields []fieldInfo
+	// The index of the ttl field, nil if no ttl field.
+	ttl []int
+}
+
+// The cache of reflect.Type => *structInfo.
+var structInfoCache sync.Map
+
+// Get the metadata of the struct type, cached per type.
+func getStructInfo(t reflect.Type) (*structInfo, error) {
+	if info, ok := structInfoCache.Load(t); ok {
+		return info.(*structInfo), nil
+	}
+
+	info := &structInfo{}
+	if err := info.collect(t, nil); err != nil {
+		return nil, err
+	}
+
+	structInfoCache.Store(t, info)
+	return info, nil
+}
+
+func (info *structInfo) collect(t reflect.Type, parent []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int(nil), parent...), i)
+		tag, tagged := f.Tag.Lookup(structTag)
+
+		if f.Anonymous && !tagged && f.Type.Kind() == reflect.Struct {
+			if err := info.collect(f.Type, index); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !tagged || tag == "-" {
+			continue
+		}
+		if f.PkgPath != "" {
+			return fmt.Errorf("cqlbuilder: tagged field %s is not exported", f.Name)
+		}
+
+		column, opts := parseTag(tag)
+		fi := fieldInfo{column: column, index: index}
+		isTtl := false
+		for _, opt := range opts {
+			switch opt {
+			case "pk":
+				fi.pk = true
+			case "ck":
+				fi.ck = true
+			case "omitempty":
+				fi.omitempty = true
+			case "ttl":
+				isTtl = true
+			default:
+				return fmt.Errorf("cqlbuilder: unknown option %q of field %s", opt, f.Name)
+			}
+		}
+
+		if isTtl {
+			if !isNumeric(f.Type.Kind()) {
+				return fmt.Errorf("cqlbuilder: ttl field %s must be numeric", f.Name)
+			}
+			if info.ttl != nil {
+				return fmt.Errorf("cqlbuilder: ttl field %s, the struct has another ttl field", f.Name)
+			}
+			info.ttl = index
+			continue
+		}
+
+		if len(fi.column) == 0 {
+			return fmt.Errorf("cqlbuilder: field %s need column name", f.Name)
+		}
+		info.fields = append(info.fields, fi)
+	}
+
+	return nil
+}
+
+// Split the tag into the column name and the options.
+func parseTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+// Get the struct value and its metadata.
+func structValue(v interface{}) (reflect.Value, *structInfo, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return rv, nil, errNotStruct
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return rv, nil, errNotStruct
+	}
+
+	info, err := getStructInfo(rv.Type())
+	return rv, info, err
+}
+
+// The ttl of the struct, 0 if no ttl field.
+func (info *structInfo) ttlOf(rv reflect.Value) int {
+	if info.ttl == nil {
+		return 0
+	}
+	fv := rv.FieldByIndex(info.ttl)
+	if fv.Type() == durationType {
+		d := time.Duration(fv.Int())
+		if d <= 0 {
+			return 0
+		}
+		return int((d + time.Second - 1) / time.Second)
+	}
+	return int(fv.Convert(reflect.TypeOf(0)).Int())
+}
+
+// Create the insert builder from the tagged struct.
+// Example:
+//
+//	type User struct {
+//		OrgID string `cql:"org_id,pk"`
+//		ID    string `cql:"id,ck"`
+//		Name  string `cql:"name,omitempty"`
+//		TTL   int    `cql:",ttl"`
+//	}
+//	ins, err := InsertStruct("users", &user)
+func InsertStruct(table string, v interface{}) (*InsertBuilder, error) {
+	rv, info, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	ins := Insert(table)
+	for _, f := range info.fields {
+		fv := rv.FieldByIndex(f.index)
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+		ins.SetValue(f.column, fv.Interface())
+	}
+
+	ins.SetTtl(info.ttlOf(rv))
+
+	return ins, nil
+}
+
+// Create the update builder from the tagged struct, the pk/ck fields go to
+// the WHERE clause and the others go to SET.
+func UpdateStruct(table string, v interface{}) (*UpdateBuilder, error) {
+	rv, info, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	up := Update(table)
+	hasKey := false
+	for _, f := range info.fields {
+		fv := rv.FieldByIndex(f.index)
+		if f.pk || f.ck {
+			up.Where(Eq(f.column, fv.Interface()))
+			hasKey = true
+			continue
+		}
+
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+		up.SetValue(f.column, fv.Interface())
+	}
+
+	if !hasKey {
+		return nil, errNoKeyColumns
+	}
+
+	up.SetTtl(info.ttlOf(rv))
+
+	return up, nil
+}
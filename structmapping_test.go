@@ -0,0 +1,96 @@
+package cqlbuilder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testBase struct {
+	OrgID string `cql:"org_id,pk"`
+}
+
+type testUser struct {
+	testBase
+	ID      string `cql:"id,ck"`
+	Name    string `cql:"name"`
+	Email   string `cql:"email,omitempty"`
+	TTL     int    `cql:",ttl"`
+	Ignored string `cql:"-"`
+	NoTag   string
+}
+
+func TestInsertStruct(t *testing.T) {
+	u := testUser{testBase: testBase{OrgID: "o1"}, ID: "u1", Name: "Alice", TTL: 60}
+	ins, err := InsertStruct("users", &u)
+	if err != nil {
+		t.Logf("err: %s", err)
+		t.FailNow()
+	}
+
+	str, vals, err := ins.ToQuery()
+	if strings.Trim(str, " ") != "INSERT INTO users(org_id,id,name) VALUES(?,?,?) USING  TTL ?" || len(vals) != 4 || vals[3] != 60 || err != nil {
+		t.Logf("str %s vals %v err %v", str, vals, err)
+		t.FailNow()
+	}
+}
+
+func TestInsertStructDurationTTL(t *testing.T) {
+	type session struct {
+		ID  string        `cql:"id,pk"`
+		TTL time.Duration `cql:",ttl"`
+	}
+
+	// The duration is the seconds, rounded up.
+	for d, expected := range map[time.Duration]int{time.Hour: 3600, 1500 * time.Millisecond: 2} {
+		ins, err := InsertStruct("sessions", session{ID: "s1", TTL: d})
+		if err != nil {
+			t.Logf("err: %s", err)
+			t.FailNow()
+		}
+		if _, vals, err := ins.ToQuery(); err != nil || len(vals) != 2 || vals[1] != expected {
+			t.Logf("ttl %v vals %v err %v", d, vals, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestUpdateStruct(t *testing.T) {
+	u := testUser{testBase: testBase{OrgID: "o1"}, ID: "u1", Name: "Alice", Email: "a@b.c"}
+	up, err := UpdateStruct("users", u)
+	if err != nil {
+		t.Logf("err: %s", err)
+		t.FailNow()
+	}
+
+	str, vals, err := up.ToQuery()
+	if strings.Trim(str, " ") != "UPDATE users SET name =? ,email =?  WHERE org_id=? AND id=?" || len(vals) != 4 || vals[2] != "o1" || err != nil {
+		t.Logf("str %s vals %v err %v", str, vals, err)
+		t.FailNow()
+	}
+}
+
+func TestStructMappingErrors(t *testing.T) {
+	if _, err := InsertStruct("users", 1); err != errNotStruct {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	type noKey struct {
+		Name string `cql:"name"`
+	}
+	if _, err := UpdateStruct("users", noKey{Name: "a"}); err != errNoKeyColumns {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+
+	type twoTTL struct {
+		ID   string `cql:"id,pk"`
+		TTL  int    `cql:",ttl"`
+		TTL2 int    `cql:",ttl"`
+	}
+	if _, err := InsertStruct("users", twoTTL{ID: "a"}); err == nil {
+		t.Logf("err expected for two ttl fields")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,214 @@
+package cqlbuilder
+
+import (
+	"fmt"
+	"reflect"
+
+	cql "github.com/gocql/gocql"
+)
+
+// Get the column names of the `cql` tagged struct, in the order of the
+// fields. v can be the struct, pointer to struct or the slice of them.
+func StructColumns(v interface{}) ([]string, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, errNotStruct
+	}
+
+	info, err := getStructInfo(baseStructType(t))
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]string, len(info.fields))
+	for i, f := range info.fields {
+		cols[i] = f.column
+	}
+	return cols, nil
+}
+
+// Get the struct type of *T, []T, *[]T, []*T.
+func baseStructType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// The column names of the result set, nil if the iterator don't know.
+func iterColumns(iter RowIterator) []string {
+	switch it := iter.(type) {
+	case *cql.Iter:
+		infos := it.Columns()
+		cols := make([]string, len(infos))
+		for i, c := range infos {
+			cols[i] = c.Name
+		}
+		return cols
+	case *SliceIterator:
+		return it.Columns
+	case *pageIterator:
+		return iterColumns(it.RowIterator)
+	case *observedIterator:
+		return iterColumns(it.RowIterator)
+	case *instrumentedIterator:
+		return iterColumns(it.RowIterator)
+	}
+	return nil
+}
+
+// Build the scan destinations of the struct fields by the result columns.
+// If the columns are unknown, the struct fields order is used. The names are
+// compared as Cassandra stores them, `cql:"userId"` is the column userid.
+func structDest(rv reflect.Value, info *structInfo, columns []string) ([]interface{}, error) {
+	if columns == nil {
+		dest := make([]interface{}, len(info.fields))
+		for i, f := range info.fields {
+			dest[i] = rv.FieldByIndex(f.index).Addr().Interface()
+		}
+		return dest, nil
+	}
+
+	dest := make([]interface{}, len(columns))
+	for i, col := range columns {
+		for _, f := range info.fields {
+			if name := canonicalName(f.column); name == col || name == canonicalName(col) {
+				dest[i] = rv.FieldByIndex(f.index).Addr().Interface()
+				break
+			}
+		}
+		if dest[i] == nil {
+			return nil, fmt.Errorf("cqlbuilder: column %s has no field in %s", col, rv.Type())
+		}
+	}
+	return dest, nil
+}
+
+// Scan the next row into the struct pointed by dest, the columns are
+// matched to the `cql` tags by name. Return false at the end of rows or
+// on error, the error of iteration is returned by iter.Close.
+func ScanStruct(iter RowIterator, dest interface{}) (bool, error) {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return false, errNotStruct
+	}
+
+	rv = rv.Elem()
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return false, err
+	}
+
+	ptrs, err := structDest(rv, info, iterColumns(iter))
+	if err != nil {
+		return false, err
+	}
+
+	return iter.Scan(ptrs...), nil
+}
+
+// Scan all the rows and append them to the slice pointed by dest, the
+// element of the slice can be struct or pointer to struct. The iterator is
+// closed.
+func ScanAll(iter RowIterator, dest interface{}) error {
+	sv := reflect.ValueOf(dest)
+	if sv.Kind() != reflect.Ptr || sv.IsNil() || sv.Elem().Kind() != reflect.Slice {
+		iter.Close()
+		return fmt.Errorf("cqlbuilder: need a pointer to slice, got %T", dest)
+	}
+
+	sv = sv.Elem()
+	elemType := sv.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	structType := baseStructType(elemType)
+	if structType.Kind() != reflect.Struct {
+		iter.Close()
+		return errNotStruct
+	}
+
+	info, err := getStructInfo(structType)
+	if err != nil {
+		iter.Close()
+		return err
+	}
+
+	columns := iterColumns(iter)
+	for {
+		row := reflect.New(structType)
+		ptrs, err := structDest(row.Elem(), info, columns)
+		if err != nil {
+			iter.Close()
+			return err
+		}
+
+		if !iter.Scan(ptrs...) {
+			break
+		}
+
+		if isPtr {
+			sv.Set(reflect.Append(sv, row))
+		} else {
+			sv.Set(reflect.Append(sv, row.Elem()))
+		}
+	}
+
+	return iter.Close()
+}
+
+// Run the select and scan the first row into the struct pointed by dest,
+// cql.ErrNotFound if no rows. If the select has no columns, the columns of
+// the struct are used.
+func SelectOne(mgr ExecManagerV2, sel *SelectBuilder, dest interface{}) error {
+	iter, err := mgr.IterRows(sel.withStructColumns(dest))
+	if err != nil {
+		return err
+	}
+
+	found, err := ScanStruct(iter, dest)
+	if err != nil {
+		iter.Close()
+		return err
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	if !found {
+		return cql.ErrNotFound
+	}
+	return nil
+}
+
+// Run the select and append all the rows to the slice pointed by dest. If
+// the select has no columns, the columns of the slice element are used.
+func SelectAll(mgr ExecManagerV2, sel *SelectBuilder, dest interface{}) error {
+	iter, err := mgr.IterRows(sel.withStructColumns(dest))
+	if err != nil {
+		return err
+	}
+
+	return ScanAll(iter, dest)
+}
+
+// The execution interface which scans the rows into the `cql` tagged structs.
+type StructExecManager interface {
+	ExecManagerV2
+
+	// Run the select and scan the first row into the struct.
+	ExecScanStruct(sel *SelectBuilder, dest interface{}) error
+
+	// Run the select and append all the rows to the slice of struct.
+	IterAll(sel *SelectBuilder, dest interface{}) error
+}
+
+var _ StructExecManager = (*SessionExecManager)(nil)
+
+// Run the select and scan the first row into the struct.
+func (em *SessionExecManager) ExecScanStruct(sel *SelectBuilder, dest interface{}) error {
+	return SelectOne(em, sel, dest)
+}
+
+// Run the select and append all the rows to the slice of struct.
+func (em *SessionExecManager) IterAll(sel *SelectBuilder, dest interface{}) error {
+	return SelectAll(em, sel, dest)
+}
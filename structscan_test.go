@@ -0,0 +1,94 @@
+package cqlbuilder
+
+import (
+	"testing"
+
+	cql "github.com/gocql/gocql"
+)
+
+type testRow struct {
+	ID   int    `cql:"id,pk"`
+	Name string `cql:"name"`
+}
+
+func TestScanAll(t *testing.T) {
+	// The result columns order don't need to match the fields.
+	iter := NewSliceIterator([]string{"name", "id"}, []interface{}{"a", 1}, []interface{}{"b", 2})
+
+	var rows []*testRow
+	if err := ScanAll(iter, &rows); err != nil || len(rows) != 2 || rows[1].ID != 2 || rows[1].Name != "b" {
+		t.Logf("err %v rows %v", err, rows)
+		t.FailNow()
+	}
+
+	iter = NewSliceIterator([]string{"id", "unknown"}, []interface{}{1, "a"})
+	var values []testRow
+	if err := ScanAll(iter, &values); err == nil {
+		t.Logf("Err expected if column has no field")
+		t.FailNow()
+	}
+
+	iter = NewSliceIterator([]string{"id", "name"}, []interface{}{"not int", "a"})
+	if err := ScanAll(iter, &values); err == nil {
+		t.Logf("Err expected if type mismatch")
+		t.FailNow()
+	}
+}
+
+func TestScanAllSession(t *testing.T) {
+	// The columns come from the iterator wrapped by SessionExecManager.
+	driver := &recordSession{rows: NewSliceIterator([]string{"name", "id"}, []interface{}{"a", int64(1)})}
+	em := &SessionExecManager{Driver: driver}
+
+	var rows []struct {
+		ID   int64  `cql:"id"`
+		Name string `cql:"name"`
+	}
+	if err := em.IterAll(Select("u").AddColumn("name").AddColumn("id").Where(Eq("id", 1)), &rows); err != nil || len(rows) != 1 || rows[0].Name != "a" {
+		t.Logf("err %v rows %v", err, rows)
+		t.FailNow()
+	}
+}
+
+func TestScanAllColumnNames(t *testing.T) {
+	// Cassandra folds the unquoted name to lower case and keeps the quoted.
+	iter := NewSliceIterator([]string{"userid", "Name"}, []interface{}{1, "a"})
+
+	var rows []struct {
+		UserID int    `cql:"userId"`
+		Name   string `cql:"\"Name\""`
+	}
+	if err := ScanAll(iter, &rows); err != nil || len(rows) != 1 || rows[0].UserID != 1 || rows[0].Name != "a" {
+		t.Logf("err %v rows %v", err, rows)
+		t.FailNow()
+	}
+}
+
+func TestSelectOne(t *testing.T) {
+	fake := NewFakeExecManager().DefineTable("t", "id")
+	fake.Exec(Insert("t").SetValue("id", 1).SetValue("name", "a"))
+
+	sel := Select("t").Where(Eq("id", 1))
+	var row testRow
+	if err := SelectOne(fake, sel, &row); err != nil || row.Name != "a" {
+		t.Logf("err %v row %v", err, row)
+		t.FailNow()
+	}
+
+	// The columns of the struct are added to the copy of the select.
+	if len(sel.colums) != 0 {
+		t.Logf("select of the caller changed %v", sel.colums)
+		t.FailNow()
+	}
+
+	var rows []testRow
+	if err := fake.IterAll(sel, &rows); err != nil || len(rows) != 1 || len(sel.colums) != 0 {
+		t.Logf("err %v rows %v columns %v", err, rows, sel.colums)
+		t.FailNow()
+	}
+
+	if err := SelectOne(fake, Select("t").Where(Eq("id", 2)), &row); err != cql.ErrNotFound {
+		t.Logf("err %v", err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,258 @@
+package cqlbuilder
+
+import (
+	"bytes"
+)
+
+const (
+	createType = " CREATE TYPE "
+	alterType  = " ALTER TYPE "
+	dropType   = " DROP TYPE "
+)
+
+// The type of create type builder which wrap the CREATE TYPE statement of
+// the user defined type.
+// Example:
+//
+//	CreateType("ks.address").IfNotExists(true).Field("street", Text).Field("zip", Int)
+type CreateTypeBuilder struct {
+	name        string
+	ifNotExists bool
+	fields      []columnDef
+}
+
+// Create the create type builder, ks.type is supported.
+func CreateType(name string) *CreateTypeBuilder {
+	return &CreateTypeBuilder{name: name}
+}
+
+// Add the field.
+func (c *CreateTypeBuilder) Field(name string, typ CqlType) *CreateTypeBuilder {
+	c.fields = append(c.fields, columnDef{name: name, typ: typ})
+	return c
+}
+
+// Set if not exists
+func (c *CreateTypeBuilder) IfNotExists(e bool) *CreateTypeBuilder {
+	c.ifNotExists = e
+	return c
+}
+
+// Validate
+func (c *CreateTypeBuilder) Validate() error {
+	if len(c.name) == 0 {
+		return &SchemaError{Statement: "CREATE TYPE", Reason: "need type name"}
+	}
+
+	if len(c.fields) == 0 {
+		return errEmptyColumn
+	}
+
+	seen := map[string]bool{}
+	for _, f := range c.fields {
+		if seen[f.name] {
+			return &SchemaError{Statement: "CREATE TYPE " + c.name, Reason: "duplicated field " + f.name}
+		}
+		seen[f.name] = true
+
+		if err := validateFieldType(f.typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The field of udt can't be counter, the nested udt must be frozen.
+func validateFieldType(typ CqlType) error {
+	if typ.UDT {
+		return &TypeError{Type: typ.String(), Reason: "nested user defined type must be frozen"}
+	}
+	if typ.Name == Counter.Name {
+		return &TypeError{Type: typ.String(), Reason: "counter can't be the field of user defined type"}
+	}
+	return typ.validate()
+}
+
+// Build the CREATE TYPE statement, it has no values.
+// Example:
+//
+//	CREATE TYPE IF NOT EXISTS ks.address (street text,zip int)
+func (c *CreateTypeBuilder) ToQuery() (string, []interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	name, err := QuoteTable(c.name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(createType)
+	if c.ifNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	buf.WriteString(name + space + leftPar)
+
+	for i, f := range c.fields {
+		field, err := QuoteIdentifier(f.name)
+		if err != nil {
+			return "", nil, err
+		}
+		typ, err := f.typ.render()
+		if err != nil {
+			return "", nil, err
+		}
+
+		if i > 0 {
+			buf.WriteString(comma)
+		}
+		buf.WriteString(field + space + typ)
+	}
+	buf.WriteString(rightPar)
+
+	return buf.String(), nil, nil
+}
+
+func (c *CreateTypeBuilder) statementTable() string {
+	return c.name
+}
+
+func (c *CreateTypeBuilder) statementKind() StatementKind {
+	return KindSchema
+}
+
+// The type of alter type builder which wrap the ALTER TYPE statement.
+// Cassandra allows one ADD, or the RENAMEs in a statement.
+type AlterTypeBuilder struct {
+	name    string
+	adds    []columnDef
+	renames []renameDef
+}
+
+// Create the alter type builder.
+func AlterType(name string) *AlterTypeBuilder {
+	return &AlterTypeBuilder{name: name}
+}
+
+// Add the field.
+func (c *AlterTypeBuilder) AddField(name string, typ CqlType) *AlterTypeBuilder {
+	c.adds = append(c.adds, columnDef{name: name, typ: typ})
+	return c
+}
+
+// Rename the field.
+func (c *AlterTypeBuilder) RenameField(from, to string) *AlterTypeBuilder {
+	c.renames = append(c.renames, renameDef{from: from, to: to})
+	return c
+}
+
+// Validate
+func (c *AlterTypeBuilder) Validate() error {
+	if len(c.name) == 0 {
+		return &SchemaError{Statement: "ALTER TYPE", Reason: "need type name"}
+	}
+
+	if len(c.adds)+len(c.renames) == 0 || len(c.adds) > 1 || len(c.adds) > 0 && len(c.renames) > 0 {
+		return &SchemaError{Statement: "ALTER TYPE " + c.name, Reason: "need one ADD, or RENAMEs"}
+	}
+
+	if len(c.adds) > 0 {
+		return validateFieldType(c.adds[0].typ)
+	}
+	return nil
+}
+
+// Build the ALTER TYPE statement, it has no values.
+func (c *AlterTypeBuilder) ToQuery() (string, []interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	name, err := QuoteTable(c.name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(alterType)
+	buf.WriteString(name)
+
+	if len(c.adds) > 0 {
+		field, err := QuoteIdentifier(c.adds[0].name)
+		if err != nil {
+			return "", nil, err
+		}
+		typ, err := c.adds[0].typ.render()
+		if err != nil {
+			return "", nil, err
+		}
+		buf.WriteString(" ADD " + field + space + typ)
+		return buf.String(), nil, nil
+	}
+
+	buf.WriteString(" RENAME ")
+	for i, r := range c.renames {
+		names, err := quoteIdentifiers([]string{r.from, r.to})
+		if err != nil {
+			return "", nil, err
+		}
+		if i > 0 {
+			buf.WriteString(and)
+		}
+		buf.WriteString(names[0] + " TO " + names[1])
+	}
+
+	return buf.String(), nil, nil
+}
+
+func (c *AlterTypeBuilder) statementTable() string {
+	return c.name
+}
+
+func (c *AlterTypeBuilder) statementKind() StatementKind {
+	return KindSchema
+}
+
+// The type of drop type builder which wrap the DROP TYPE statement.
+type DropTypeBuilder struct {
+	name     string
+	ifExists bool
+}
+
+// Create the drop type builder.
+func DropType(name string) *DropTypeBuilder {
+	return &DropTypeBuilder{name: name}
+}
+
+// Set if exists
+func (c *DropTypeBuilder) IfExists(e bool) *DropTypeBuilder {
+	c.ifExists = e
+	return c
+}
+
+// Build the DROP TYPE statement, it has no values.
+func (c *DropTypeBuilder) ToQuery() (string, []interface{}, error) {
+	if len(c.name) == 0 {
+		return "", nil, &SchemaError{Statement: "DROP TYPE", Reason: "need type name"}
+	}
+
+	name, err := QuoteTable(c.name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	str := dropType
+	if c.ifExists {
+		str += ifExists
+	}
+	return str + name, nil, nil
+}
+
+func (c *DropTypeBuilder) statementTable() string {
+	return c.name
+}
+
+func (c *DropTypeBuilder) statementKind() StatementKind {
+	return KindSchema
+}
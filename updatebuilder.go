@@ -12,6 +12,9 @@ type UpdateBuilder struct {
 	whereConditions []conditionBuilder
 	ifConditions    []conditionBuilder
 	table           string
+	ttl             int
+	options         *QueryOptions
+	schema          *TableSchema
 }
 
 // Set a value
@@ -22,6 +25,12 @@ func (c *UpdateBuilder) SetValue(name string, value interface{}) *UpdateBuilder
 	return c
 }
 
+// Set the ttl(seconds) of the updated columns.
+func (c *UpdateBuilder) SetTtl(t int) *UpdateBuilder {
+	c.ttl = t
+	return c
+}
+
 // Set the where condition.
 func (c *UpdateBuilder) Where(condition conditionBuilder) *UpdateBuilder {
 	c.whereConditions = append(c.whereConditions, condition)
@@ -60,11 +69,29 @@ func (c *UpdateBuilder) ToQuery() (string, []interface{}, error) {
 		return "", nil, err
 	}
 
+	if c.schema != nil {
+		if err := c.schema.validateUpdate(c); err != nil {
+			return "", nil, err
+		}
+	}
+
+	table, err := QuoteTable(c.table)
+	if err != nil {
+		return "", nil, err
+	}
+
 	var buf bytes.Buffer
-	values := make([]interface{}, 0, len(c.values))
+	values := make([]interface{}, 0, len(c.values)+1)
 
 	buf.WriteString(update)
-	buf.WriteString(c.table)
+	buf.WriteString(table)
+
+	if c.ttl > 0 {
+		buf.WriteString(using)
+		buf.WriteString(ttl)
+		values = append(values, c.ttl)
+	}
+
 	buf.WriteString(set)
 
 	for i := 0; i < len(c.colums); i++ {
@@ -76,19 +103,30 @@ func (c *UpdateBuilder) ToQuery() (string, []interface{}, error) {
 			buf.WriteString(comma)
 		}
 
-		buf.WriteString(c.colums[i])
+		col, err := quoteTarget(c.colums[i])
+		if err != nil {
+			return "", nil, err
+		}
+
+		buf.WriteString(col)
 		buf.WriteString(eq)
 	}
 
 	values = append(values, c.values...)
 
-	condition, conditionValues := buildCondition(c.whereConditions)
+	condition, conditionValues, err := buildCondition(c.whereConditions)
+	if err != nil {
+		return "", nil, err
+	}
 	buf.WriteString(where)
 	buf.WriteString(condition)
 	values = append(values, conditionValues...)
 
 	if len(c.ifConditions) > 0 {
-		condition, conditionValues = buildCondition(c.ifConditions)
+		condition, conditionValues, err = buildCondition(c.ifConditions)
+		if err != nil {
+			return "", nil, err
+		}
 		buf.WriteString(ifs)
 		buf.WriteString(condition)
 		values = append(values, conditionValues...)
@@ -96,3 +134,28 @@ func (c *UpdateBuilder) ToQuery() (string, []interface{}, error) {
 
 	return buf.String(), values, nil
 }
+
+// Attach the query options(consistency, page size ...) to the statement.
+func (c *UpdateBuilder) SetOptions(o *QueryOptions) *UpdateBuilder {
+	c.options = o
+	return c
+}
+
+// Bind the statement to the table schema, ToQuery validates the columns,
+// keys and value types by it.
+func (c *UpdateBuilder) SetSchema(s *TableSchema) *UpdateBuilder {
+	c.schema = s
+	return c
+}
+
+func (c *UpdateBuilder) queryOptions() *QueryOptions {
+	return c.options
+}
+
+func (c *UpdateBuilder) statementTable() string {
+	return c.table
+}
+
+func (c *UpdateBuilder) statementKind() StatementKind {
+	return KindUpdate
+}
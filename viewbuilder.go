@@ -0,0 +1,261 @@
+package cqlbuilder
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	createView = " CREATE MATERIALIZED VIEW "
+	dropView   = " DROP MATERIALIZED VIEW "
+)
+
+// The type of create materialized view builder. The column list, the base
+// table and the IS NOT NULL conditions come from the select builder, the
+// IS NOT NULL of the view primary key columns are added if missing.
+// Example:
+//
+//	sel := Select("ks.users").AddColumns("id", "email", "name")
+//	CreateMaterializedView("ks.users_by_email", sel).PartitionKey("email").ClusteringKey("id")
+type CreateViewBuilder struct {
+	name           string
+	sel            *SelectBuilder
+	ifNotExists    bool
+	partitionKeys  []string
+	clusteringKeys []string
+	orders         []clusteringOrderDef
+	options        tableOptions
+}
+
+// Create the create materialized view builder.
+func CreateMaterializedView(name string, sel *SelectBuilder) *CreateViewBuilder {
+	return &CreateViewBuilder{name: name, sel: sel}
+}
+
+// Add the partition key columns of the view.
+func (c *CreateViewBuilder) PartitionKey(columns ...string) *CreateViewBuilder {
+	c.partitionKeys = append(c.partitionKeys, columns...)
+	return c
+}
+
+// Add the clustering key columns of the view.
+func (c *CreateViewBuilder) ClusteringKey(columns ...string) *CreateViewBuilder {
+	c.clusteringKeys = append(c.clusteringKeys, columns...)
+	return c
+}
+
+// Add the CLUSTERING ORDER BY of the clustering column.
+func (c *CreateViewBuilder) ClusteringOrder(column string, desc bool) *CreateViewBuilder {
+	c.orders = append(c.orders, clusteringOrderDef{column: column, desc: desc})
+	return c
+}
+
+// Set if not exists
+func (c *CreateViewBuilder) IfNotExists(e bool) *CreateViewBuilder {
+	c.ifNotExists = e
+	return c
+}
+
+// Set the table option of the view, see CreateTableBuilder.With.
+func (c *CreateViewBuilder) With(name string, value interface{}) *CreateViewBuilder {
+	c.options.set(name, value)
+	return c
+}
+
+func (c *CreateViewBuilder) schemaError(reason string) error {
+	return &SchemaError{Statement: "CREATE MATERIALIZED VIEW " + c.name, Reason: reason}
+}
+
+// Validate
+func (c *CreateViewBuilder) Validate() error {
+	if len(c.name) == 0 {
+		return c.schemaError("need view name")
+	}
+
+	if c.sel == nil || len(c.sel.table) == 0 {
+		return errEmptyTable
+	}
+
+	if len(c.partitionKeys) == 0 {
+		return c.schemaError("need partition key")
+	}
+
+	for _, cond := range c.sel.whereConditions {
+		if _, ok := cond.(*notNullBuilder); !ok {
+			return c.schemaError("only IS NOT NULL is supported in WHERE")
+		}
+	}
+
+	selected := map[string]bool{}
+	for _, col := range c.sel.colums {
+		selected[col] = true
+	}
+
+	seen := map[string]bool{}
+	for _, k := range append(append([]string(nil), c.partitionKeys...), c.clusteringKeys...) {
+		if seen[k] {
+			return c.schemaError("duplicated key column " + k)
+		}
+		seen[k] = true
+
+		if len(selected) > 0 && !selected["*"] && !selected[k] {
+			return c.schemaError("key column " + k + " is not selected")
+		}
+	}
+
+	for i, o := range c.orders {
+		if i >= len(c.clusteringKeys) || c.clusteringKeys[i] != o.column {
+			return c.schemaError("clustering order must follow the clustering keys, got " + o.column)
+		}
+	}
+
+	return nil
+}
+
+// Build the CREATE MATERIALIZED VIEW statement, it has no values.
+// Example:
+//
+//	CREATE MATERIALIZED VIEW ks.users_by_email AS SELECT id,email,name FROM ks.users WHERE email IS NOT NULL AND id IS NOT NULL PRIMARY KEY (email,id)
+func (c *CreateViewBuilder) ToQuery() (string, []interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	name, err := QuoteTable(c.name)
+	if err != nil {
+		return "", nil, err
+	}
+	table, err := QuoteTable(c.sel.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns := []string{"*"}
+	if len(c.sel.colums) > 0 {
+		if columns, err = quoteColumns(c.sel.colums); err != nil {
+			return "", nil, err
+		}
+	}
+
+	pks, err := quoteIdentifiers(c.partitionKeys)
+	if err != nil {
+		return "", nil, err
+	}
+	cks, err := quoteIdentifiers(c.clusteringKeys)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The IS NOT NULL of the select, plus the missing ones of the keys.
+	conds := append([]conditionBuilder(nil), c.sel.whereConditions...)
+	restricted := map[string]bool{}
+	for _, cond := range conds {
+		restricted[cond.(*notNullBuilder).column] = true
+	}
+	for _, k := range append(append([]string(nil), c.partitionKeys...), c.clusteringKeys...) {
+		if !restricted[k] {
+			conds = append(conds, IsNotNull(k))
+		}
+	}
+	cond, _, err := buildCondition(conds)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(createView)
+	if c.ifNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	buf.WriteString(name)
+	buf.WriteString(" AS SELECT ")
+	buf.WriteString(strings.Join(columns, comma))
+	buf.WriteString(from + table)
+	buf.WriteString(where + cond)
+	buf.WriteString(space + primaryKey + leftPar)
+	if len(pks) > 1 {
+		buf.WriteString(leftPar + strings.Join(pks, comma) + rightPar)
+	} else {
+		buf.WriteString(pks[0])
+	}
+	for _, ck := range cks {
+		buf.WriteString(comma + ck)
+	}
+	buf.WriteString(rightPar)
+
+	withs := make([]string, 0, 2)
+	if len(c.orders) > 0 {
+		orders := make([]string, len(c.orders))
+		for i, o := range c.orders {
+			if o.desc {
+				orders[i] = cks[i] + desc
+			} else {
+				orders[i] = cks[i] + asc
+			}
+		}
+		withs = append(withs, clusteringOrder+leftPar+strings.Join(orders, comma)+rightPar)
+	}
+	if !c.options.empty() {
+		opts, err := c.options.render()
+		if err != nil {
+			return "", nil, err
+		}
+		withs = append(withs, opts)
+	}
+	if len(withs) > 0 {
+		buf.WriteString(with + strings.Join(withs, and))
+	}
+
+	return buf.String(), nil, nil
+}
+
+func (c *CreateViewBuilder) statementTable() string {
+	return c.name
+}
+
+func (c *CreateViewBuilder) statementKind() StatementKind {
+	return KindSchema
+}
+
+// The type of drop materialized view builder.
+type DropViewBuilder struct {
+	name     string
+	ifExists bool
+}
+
+// Create the drop materialized view builder.
+func DropMaterializedView(name string) *DropViewBuilder {
+	return &DropViewBuilder{name: name}
+}
+
+// Set if exists
+func (c *DropViewBuilder) IfExists(e bool) *DropViewBuilder {
+	c.ifExists = e
+	return c
+}
+
+// Build the DROP MATERIALIZED VIEW statement, it has no values.
+func (c *DropViewBuilder) ToQuery() (string, []interface{}, error) {
+	if len(c.name) == 0 {
+		return "", nil, &SchemaError{Statement: "DROP MATERIALIZED VIEW", Reason: "need view name"}
+	}
+
+	name, err := QuoteTable(c.name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	str := dropView
+	if c.ifExists {
+		str += ifExists
+	}
+	return str + name, nil, nil
+}
+
+func (c *DropViewBuilder) statementTable() string {
+	return c.name
+}
+
+func (c *DropViewBuilder) statementKind() StatementKind {
+	return KindSchema
+}